@@ -2,16 +2,20 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
-
-	"github.com/vishvananda/netlink"
+	"time"
 
 	"github.com/openshift/pf-status-relay/pkg/config"
+	"github.com/openshift/pf-status-relay/pkg/health"
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
 	"github.com/openshift/pf-status-relay/pkg/lacp"
 	"github.com/openshift/pf-status-relay/pkg/log"
+	"github.com/openshift/pf-status-relay/pkg/metrics"
+	"github.com/openshift/pf-status-relay/pkg/server"
 	"github.com/openshift/pf-status-relay/pkg/subscribe"
 )
 
@@ -29,6 +33,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Configure logs.
+	err = log.Configure(conf.Log)
+	if err != nil {
+		log.Log.Error("failed to configure logs", "error", err)
+		os.Exit(1)
+	}
+
+	// Toggle debug logs on SIGUSR1.
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	go func() {
+		for range usr1 {
+			log.Log.Info("debug logs were toggled", "enabled", log.ToggleDebug())
+		}
+	}()
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Queue to store link events.
@@ -37,12 +57,44 @@ func main() {
 	var wg sync.WaitGroup
 
 	// Initialize interfaces.
-	pfs := lacp.New(conf.Interfaces, queue, conf.PollingInterval, &netlink.Handle{})
+	pfs := lacp.New(conf, queue, interfaces.Instrument(&interfaces.Handle{}))
 	if len(pfs.PFs) == 0 {
 		log.Log.Error("no interfaces found in node")
 		os.Exit(1)
 	}
 
+	// The subscription beats while it waits for link changes.
+	subscription := health.NewHeartbeat("subscribe", time.Duration(conf.StallTimeout)*time.Millisecond)
+
+	// Serve metrics, probes and the status of the PFs.
+	if conf.HTTPAddress != "" {
+		pfs.Register(metrics.DefaultRegistry)
+		metrics.DefaultRegistry.Register(metrics.NewGauge("pf_status_relay_event_queue_depth", "Number of link events waiting to be processed.", func(emit func(float64, ...string)) {
+			emit(float64(len(queue)))
+		}))
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.DefaultRegistry.Handler())
+		mux.Handle("/healthz", health.Handler(health.Liveness(pfs.Heartbeat(), subscription)))
+		mux.Handle("/readyz", health.Handler(pfs.Readiness))
+		mux.Handle("/status", pfs.StatusHandler())
+		mux.Handle("/status/", pfs.StatusHandler())
+		err = server.Start(ctx, conf.HTTPAddress, mux, &wg)
+		if err != nil {
+			log.Log.Error("failed to start http server", "address", conf.HTTPAddress, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Serve the status of the PFs on a unix socket.
+	if conf.StatusSocket != "" {
+		err = server.StartUnix(ctx, conf.StatusSocket, pfs.StatusHandler(), &wg)
+		if err != nil {
+			log.Log.Error("failed to serve status", "path", conf.StatusSocket, "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// Start inspection.
 	pfs.Inspect(ctx, &wg)
 
@@ -50,7 +102,7 @@ func main() {
 	pfs.Monitor(ctx, &wg)
 
 	// Start subscription to link changes.
-	err = subscribe.Start(ctx, pfs.Indexes(), queue, &wg)
+	err = subscribe.Start(ctx, pfs.Indexes(), queue, &wg, subscription)
 	if err != nil {
 		log.Log.Error("failed to subscribe to link changes", "error", err)
 	}
@@ -58,4 +110,7 @@ func main() {
 	<-c
 	cancel()
 	wg.Wait()
+
+	// Apply the shutdown policy to the VFs that were disabled.
+	pfs.Shutdown()
 }
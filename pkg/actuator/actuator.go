@@ -0,0 +1,127 @@
+package actuator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+// Actuator relays the health of a PF to the interfaces that depend on it.
+type Actuator interface {
+	// Name returns the name of the actuator.
+	Name() string
+	// Apply relays the health of the PF described by link.
+	Apply(link netlink.Link, healthy bool) error
+}
+
+// Stateful is implemented by actuators that own VFs whose state must be restored after a restart.
+type Stateful interface {
+	// Owned returns the id of the VFs owned by the actuator mapped to their original link state.
+	Owned() map[int]uint32
+	// Restore takes ownership of the VFs owned by a previous instance, dropping those that are no longer in the
+	// state set by the actuator on link.
+	Restore(link netlink.Link, owned map[int]uint32)
+	// Release restores the VFs owned by the actuator to their original state, until ctx is done.
+	Release(ctx context.Context, link netlink.Link) error
+}
+
+// Idler is implemented by actuators that can tell when relaying a healthy PF would not change any VF, so that
+// the info of the VFs does not need to be fetched.
+type Idler interface {
+	// Idle returns true when relaying a healthy PF would not change any VF.
+	Idle() bool
+}
+
+// Unwrap returns the actuators that are combined in a.
+func Unwrap(a Actuator) []Actuator {
+	switch a := a.(type) {
+	case Chain:
+		var actuators []Actuator
+		for _, c := range a {
+			actuators = append(actuators, Unwrap(c)...)
+		}
+		return actuators
+	case *selected:
+		return Unwrap(a.Actuator)
+	}
+
+	return []Actuator{a}
+}
+
+// Factory creates an actuator for the PF with the given name.
+type Factory func(pf string, nl interfaces.Netlink, options map[string]string) (Actuator, error)
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Factory)
+)
+
+// Register makes an actuator available by name. It panics if name is already registered.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic("actuator: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New returns an actuator registered with name for the given PF.
+func New(name, pf string, nl interfaces.Netlink, options map[string]string) (Actuator, error) {
+	mu.RLock()
+	factory, ok := registry[name]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown actuator %q", name)
+	}
+
+	return factory(pf, nl, options)
+}
+
+// Registered returns the sorted names of the registered actuators.
+func Registered() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Chain is an actuator that applies the health with every one of its actuators.
+type Chain []Actuator
+
+// Name returns the names of the chained actuators.
+func (c Chain) Name() string {
+	names := make([]string, 0, len(c))
+	for _, a := range c {
+		names = append(names, a.Name())
+	}
+
+	return strings.Join(names, ",")
+}
+
+// Apply runs every actuator of the chain, even if a previous one failed.
+func (c Chain) Apply(link netlink.Link, healthy bool) error {
+	var errs []error
+	for _, a := range c {
+		if err := a.Apply(link, healthy); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", a.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,258 @@
+package actuator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/sys/unix"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+var _ = Describe("Actuator", func() {
+	var (
+		ctrl        *gomock.Controller
+		mockNetlink *interfaces.MockNetlink
+		link        *netlink.Dummy
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockNetlink = interfaces.NewMockNetlink(ctrl)
+
+		link = &netlink.Dummy{
+			LinkAttrs: netlink.LinkAttrs{
+				Index: 1,
+				Name:  "test",
+				Vfs: []netlink.VfInfo{
+					{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO},
+					{ID: 1, LinkState: netlink.VF_LINK_STATE_DISABLE},
+					{ID: 2, LinkState: netlink.VF_LINK_STATE_ENABLE},
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("Registry", func() {
+		It("should create a registered actuator", func() {
+			a, err := New("vfstate", "test", mockNetlink, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Name()).To(Equal("vfstate"))
+		})
+
+		It("should return an error for an unknown actuator", func() {
+			_, err := New("unknown", "test", mockNetlink, nil)
+			Expect(err).To(MatchError(`unknown actuator "unknown"`))
+		})
+	})
+
+	Describe("VfState", func() {
+		It("should disable the VFs that are not disabled when the PF is not healthy", func() {
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1)
+			mockNetlink.EXPECT().LinkSetVfState(link, 2, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1)
+
+			err := NewVfState("test", mockNetlink).Apply(link, false)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should restore only the VFs it disabled to their original state when the PF is healthy", func() {
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1)
+			mockNetlink.EXPECT().LinkSetVfState(link, 2, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1)
+
+			v := NewVfState("test", mockNetlink)
+			Expect(v.Apply(link, false)).To(Succeed())
+			Expect(v.owned).To(Equal(map[int]uint32{0: netlink.VF_LINK_STATE_AUTO, 2: netlink.VF_LINK_STATE_ENABLE}))
+
+			for i := range link.Vfs {
+				link.Vfs[i].LinkState = netlink.VF_LINK_STATE_DISABLE
+			}
+
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_AUTO)).Return(nil).Times(1)
+			mockNetlink.EXPECT().LinkSetVfState(link, 2, uint32(netlink.VF_LINK_STATE_ENABLE)).Return(nil).Times(1)
+			Expect(v.Apply(link, true)).To(Succeed())
+			Expect(v.owned).To(BeEmpty())
+		})
+
+		It("should not restore VFs disabled by someone else", func() {
+			err := NewVfState("test", mockNetlink).Apply(link, true)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should forget VFs whose state was changed externally", func() {
+			v := NewVfState("test", mockNetlink)
+			v.owned = map[int]uint32{0: netlink.VF_LINK_STATE_ENABLE, 5: netlink.VF_LINK_STATE_AUTO}
+
+			Expect(v.Apply(link, true)).To(Succeed())
+			Expect(v.owned).To(BeEmpty())
+		})
+
+		It("should restore the ownership of VFs that are still disabled", func() {
+			v := NewVfState("test", mockNetlink)
+			v.Restore(link, map[int]uint32{0: netlink.VF_LINK_STATE_ENABLE, 1: netlink.VF_LINK_STATE_AUTO, 7: netlink.VF_LINK_STATE_AUTO})
+			Expect(v.Owned()).To(Equal(map[int]uint32{1: netlink.VF_LINK_STATE_AUTO}))
+
+			mockNetlink.EXPECT().LinkSetVfState(link, 1, uint32(netlink.VF_LINK_STATE_AUTO)).Return(nil).Times(1)
+			Expect(v.Apply(link, true)).To(Succeed())
+		})
+
+		It("should release the VFs it owns until the deadline", func() {
+			v := NewVfState("test", mockNetlink)
+			v.Restore(link, map[int]uint32{1: netlink.VF_LINK_STATE_ENABLE})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			Expect(v.Release(ctx, link)).To(MatchError("vf 1: context canceled"))
+			Expect(v.Owned()).To(HaveLen(1))
+
+			mockNetlink.EXPECT().LinkSetVfState(link, 1, uint32(netlink.VF_LINK_STATE_ENABLE)).Return(nil).Times(1)
+			Expect(v.Release(context.Background(), link)).To(Succeed())
+			Expect(v.Owned()).To(BeEmpty())
+		})
+
+		It("should bring the VFs to the configured healthy state and correct drift", func() {
+			a, err := New("vfstate", "test", mockNetlink, map[string]string{"healthy": "enable"})
+			Expect(err).NotTo(HaveOccurred())
+			v := a.(*VfState)
+			v.Restore(link, map[int]uint32{1: netlink.VF_LINK_STATE_AUTO})
+
+			// VF 0 drifted to auto, VF 1 was disabled by the actuator and VF 2 is already enabled.
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_ENABLE)).Return(nil).Times(1)
+			mockNetlink.EXPECT().LinkSetVfState(link, 1, uint32(netlink.VF_LINK_STATE_ENABLE)).Return(nil).Times(1)
+			Expect(v.Apply(link, true)).To(Succeed())
+			Expect(v.Owned()).To(BeEmpty())
+		})
+
+		It("should not correct VFs disabled by someone else", func() {
+			a, err := New("vfstate", "test", mockNetlink, map[string]string{"healthy": "auto"})
+			Expect(err).NotTo(HaveOccurred())
+
+			mockNetlink.EXPECT().LinkSetVfState(link, 2, uint32(netlink.VF_LINK_STATE_AUTO)).Return(nil).Times(1)
+			Expect(a.Apply(link, true)).To(Succeed())
+		})
+
+		It("should reject an invalid healthy state", func() {
+			_, err := New("vfstate", "test", mockNetlink, map[string]string{"healthy": "disable"})
+			Expect(err).To(MatchError(`invalid vfstate healthy state "disable"`))
+		})
+
+		It("should return an error when a VF cannot be set", func() {
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(errors.New("failed")).Times(1)
+			mockNetlink.EXPECT().LinkSetVfState(link, 2, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1)
+
+			v := NewVfState("test", mockNetlink)
+			err := v.Apply(link, false)
+			Expect(err).To(MatchError("vf 0: failed"))
+			Expect(v.owned).To(Equal(map[int]uint32{2: netlink.VF_LINK_STATE_ENABLE}))
+		})
+	})
+
+	Describe("Retry", func() {
+		var v *VfState
+
+		BeforeEach(func() {
+			link.Vfs = link.Vfs[:1]
+			v = NewVfState("test", mockNetlink)
+			v.SetRetry(Retry{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, Verify: true})
+		})
+
+		It("should verify the state of the VF after setting it", func() {
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1)
+			mockNetlink.EXPECT().LinkByIndex(1).Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{
+				Vfs: []netlink.VfInfo{{ID: 0, LinkState: netlink.VF_LINK_STATE_DISABLE}},
+			}}, nil).Times(1)
+
+			Expect(v.Apply(link, false)).To(Succeed())
+			Expect(v.Owned()).To(HaveKey(0))
+		})
+
+		It("should verify the state of all VFs with a single request", func() {
+			link.Vfs = []netlink.VfInfo{
+				{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO},
+				{ID: 1, LinkState: netlink.VF_LINK_STATE_AUTO},
+				{ID: 2, LinkState: netlink.VF_LINK_STATE_AUTO},
+			}
+			mockNetlink.EXPECT().LinkSetVfState(link, gomock.Any(), uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(3)
+			mockNetlink.EXPECT().LinkByIndex(1).Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Vfs: []netlink.VfInfo{
+				{ID: 0, LinkState: netlink.VF_LINK_STATE_DISABLE},
+				{ID: 1, LinkState: netlink.VF_LINK_STATE_DISABLE},
+				{ID: 2, LinkState: netlink.VF_LINK_STATE_DISABLE},
+			}}}, nil).Times(1)
+
+			Expect(v.Apply(link, false)).To(Succeed())
+			Expect(v.Owned()).To(HaveLen(3))
+		})
+
+		It("should not verify the state of the VFs when disabled", func() {
+			v.SetRetry(Retry{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1)
+
+			Expect(v.Apply(link, false)).To(Succeed())
+			Expect(v.Owned()).To(HaveKey(0))
+		})
+
+		It("should stop waiting to retry when the context is done", func() {
+			v.SetRetry(Retry{Attempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour})
+			v.owned[0] = netlink.VF_LINK_STATE_AUTO
+			link.Vfs[0].LinkState = netlink.VF_LINK_STATE_DISABLE
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_AUTO)).Return(unix.EBUSY).Times(1)
+
+			err := v.Release(ctx, link)
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(v.Owned()).To(HaveKey(0))
+		})
+
+		It("should retry transient errors", func() {
+			gomock.InOrder(
+				mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(unix.EBUSY).Times(2),
+				mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1),
+			)
+			mockNetlink.EXPECT().LinkByIndex(1).Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{
+				Vfs: []netlink.VfInfo{{ID: 0, LinkState: netlink.VF_LINK_STATE_DISABLE}},
+			}}, nil).Times(1)
+
+			Expect(v.Apply(link, false)).To(Succeed())
+		})
+
+		It("should not retry permanent errors", func() {
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(unix.EOPNOTSUPP).Times(1)
+
+			err := v.Apply(link, false)
+			Expect(err).To(MatchError(unix.EOPNOTSUPP))
+			Expect(Permanent(err)).To(BeTrue())
+			Expect(v.Owned()).To(BeEmpty())
+		})
+
+		It("should fail after the last attempt when the state is not applied", func() {
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(3)
+			mockNetlink.EXPECT().LinkByIndex(1).Return(link, nil).Times(3)
+
+			err := v.Apply(link, false)
+			Expect(err).To(MatchError(ErrNotApplied))
+			Expect(err).To(MatchError("vf 0: state was not applied: link state is auto, expected disable"))
+			Expect(Permanent(err)).To(BeFalse())
+			Expect(v.Owned()).To(BeEmpty())
+		})
+	})
+
+	Describe("Chain", func() {
+		It("should apply every actuator and join errors", func() {
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(errors.New("failed")).Times(2)
+			mockNetlink.EXPECT().LinkSetVfState(link, 2, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(2)
+
+			c := Chain{NewVfState("test", mockNetlink), NewVfState("test", mockNetlink)}
+			err := c.Apply(link, false)
+			Expect(err).To(MatchError("vfstate: vf 0: failed\nvfstate: vf 0: failed"))
+		})
+	})
+})
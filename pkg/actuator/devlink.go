@@ -0,0 +1,202 @@
+package actuator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+func init() {
+	Register("devlink", func(pf string, nl interfaces.Netlink, _ map[string]string) (Actuator, error) {
+		return NewDevlink(pf, nl), nil
+	})
+}
+
+// Devlink relays the health of a PF in switchdev mode by setting the state of the devlink port function of its
+// VFs. The port of a VF is found through its representor. Like VfState, it only restores the VFs it deactivated.
+type Devlink struct {
+	ledger
+	nl interfaces.Netlink
+	// sysfs is the mount point of sysfs, where PCI addresses and representor names are read from.
+	sysfs string
+}
+
+// NewDevlink returns a Devlink actuator for the given PF.
+func NewDevlink(pf string, handle interfaces.Netlink) *Devlink {
+	return &Devlink{ledger: newLedger(pf, "port function state", nl.DEVLINK_PORT_FN_STATE_INACTIVE, fnStateName), nl: handle, sysfs: "/sys"}
+}
+
+// Name returns the name of the actuator.
+func (d *Devlink) Name() string {
+	return "devlink"
+}
+
+// Apply deactivates the port function of the VFs when the PF is not healthy, and activates the ones it
+// deactivated otherwise.
+func (d *Devlink) Apply(link netlink.Link, healthy bool) error {
+	ports, err := d.ports()
+	if err != nil {
+		return err
+	}
+
+	states, serr := d.states(link, ports)
+	return errors.Join(serr, d.apply(link, states, healthy, original, d.setter(ports), d.getter(link)))
+}
+
+// Restore takes ownership of the VFs deactivated by a previous instance that are still inactive.
+func (d *Devlink) Restore(link netlink.Link, owned map[int]uint32) {
+	ports, err := d.ports()
+	if err != nil {
+		// The VFs are kept owned until their state can be read.
+		log.Log.Error("failed to list devlink ports", "interface", d.pf, "error", err)
+	}
+
+	states, _ := d.states(link, ports)
+	d.restore(link, states, owned)
+}
+
+// Release activates every VF deactivated by the actuator.
+func (d *Devlink) Release(ctx context.Context, link netlink.Link) error {
+	ports, err := d.ports()
+	if err != nil {
+		return err
+	}
+
+	states, _ := d.states(link, ports)
+	return d.release(ctx, link, states, original, d.setter(ports), d.getter(link))
+}
+
+// ports returns the devlink ports of the VFs of the PF by VF id.
+func (d *Devlink) ports() (map[int]*netlink.DevlinkPort, error) {
+	device, err := os.Readlink(filepath.Join(d.sysfs, "class", "net", d.pf, "device"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pci device: %w", err)
+	}
+	device = filepath.Base(device)
+
+	all, err := d.nl.DevLinkGetAllPortList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devlink ports: %w", err)
+	}
+
+	// The VF ports of both uplinks of a NIC can share a devlink instance, the PF number tells them apart.
+	number := pfNumber(d.sysfs, d.pf)
+	ports := make(map[int]*netlink.DevlinkPort)
+	for _, port := range all {
+		if port.BusName != "pci" || port.DeviceName != device || port.PortFlavour != nl.DEVLINK_PORT_FLAVOUR_PCI_VF || port.NetdeviceName == "" {
+			continue
+		}
+
+		pf, id, ok := represented(d.sysfs, port.NetdeviceName)
+		if !ok || (number >= 0 && pf != number) {
+			continue
+		}
+		ports[id] = port
+	}
+
+	return ports, nil
+}
+
+// states returns the state of the port function of the VFs of link.
+func (d *Devlink) states(link netlink.Link, ports map[int]*netlink.DevlinkPort) (map[int]uint32, error) {
+	var errs []error
+	states := make(map[int]uint32, len(link.Attrs().Vfs))
+	for _, vf := range link.Attrs().Vfs {
+		port, ok := ports[vf.ID]
+		if !ok {
+			errs = append(errs, fmt.Errorf("vf %d: devlink port not found", vf.ID))
+			continue
+		}
+		if port.Fn == nil {
+			errs = append(errs, fmt.Errorf("vf %d: port function is not supported by %s/%s", vf.ID, port.BusName, port.DeviceName))
+			continue
+		}
+		states[vf.ID] = uint32(port.Fn.State)
+	}
+
+	return states, errors.Join(errs...)
+}
+
+// setter returns a function that sets the state of the port function of a VF.
+func (d *Devlink) setter(ports map[int]*netlink.DevlinkPort) setFunc {
+	return func(id int, state uint32) error {
+		port := ports[id]
+		err := d.nl.DevlinkPortFnSet(port.BusName, port.DeviceName, port.PortIndex, netlink.DevlinkPortFnSetAttrs{
+			FnAttrs:    netlink.DevlinkPortFn{State: uint8(state)},
+			StateValid: true,
+		})
+		return err
+	}
+}
+
+// getter returns a function that reads the state of the port function of the VFs of link, with a single dump of
+// the devlink ports.
+func (d *Devlink) getter(link netlink.Link) getFunc {
+	return func() (map[int]uint32, error) {
+		ports, err := d.ports()
+		if err != nil {
+			return nil, err
+		}
+
+		return d.states(link, ports)
+	}
+}
+
+// represented returns the PF number and the VF id represented by the representor name, from its physical port
+// name (i.e. "pf0vf3").
+func represented(sysfs, name string) (int, int, bool) {
+	var pf, vf int
+	_, err := fmt.Sscanf(physPortName(sysfs, name), "pf%dvf%d", &pf, &vf)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return pf, vf, true
+}
+
+// pfNumber returns the PF number of pf from the physical port name of its uplink representor (i.e. "p0"), or -1
+// when it has none.
+func pfNumber(sysfs, pf string) int {
+	var number int
+	if _, err := fmt.Sscanf(physPortName(sysfs, pf), "p%d", &number); err != nil {
+		return -1
+	}
+
+	return number
+}
+
+// physPortName returns the physical port name of an interface, or an empty string if it has none.
+func physPortName(sysfs, name string) string {
+	raw, err := os.ReadFile(filepath.Join(sysfs, "class", "net", name, "phys_port_name"))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(raw))
+}
+
+// original returns the original state of a VF.
+func original(state uint32) uint32 {
+	return state
+}
+
+// fnStateName returns the name of a port function state.
+func fnStateName(state uint32) string {
+	switch state {
+	case nl.DEVLINK_PORT_FN_STATE_ACTIVE:
+		return "active"
+	case nl.DEVLINK_PORT_FN_STATE_INACTIVE:
+		return "inactive"
+	}
+
+	return fmt.Sprintf("unknown(%d)", state)
+}
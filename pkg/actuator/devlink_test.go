@@ -0,0 +1,132 @@
+package actuator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+var _ = Describe("Devlink", func() {
+	var (
+		ctrl        *gomock.Controller
+		mockNetlink *interfaces.MockNetlink
+		d           *Devlink
+		link        *netlink.Dummy
+		ports       []*netlink.DevlinkPort
+	)
+
+	// representor creates the sysfs entries of the representor of a VF.
+	representor := func(sysfs, name, physPortName string) {
+		Expect(os.MkdirAll(filepath.Join(sysfs, "class", "net", name), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(sysfs, "class", "net", name, "phys_port_name"), []byte(physPortName+"\n"), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockNetlink = interfaces.NewMockNetlink(ctrl)
+
+		sysfs := GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(sysfs, "class", "net", "test"), 0o755)).To(Succeed())
+		Expect(os.Symlink("../../../devices/pci0000:00/0000:08:00.0", filepath.Join(sysfs, "class", "net", "test", "device"))).To(Succeed())
+		representor(sysfs, "eth0", "pf0vf0")
+		representor(sysfs, "eth1", "pf0vf1")
+		representor(sysfs, "eth2", "pf1vf0")
+
+		d = NewDevlink("test", mockNetlink)
+		d.sysfs = sysfs
+
+		link = &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test", Vfs: []netlink.VfInfo{{ID: 0}, {ID: 1}}}}
+		ports = []*netlink.DevlinkPort{
+			{BusName: "pci", DeviceName: "0000:08:00.0", PortIndex: 1, PortFlavour: nl.DEVLINK_PORT_FLAVOUR_PHYSICAL, NetdeviceName: "test"},
+			{BusName: "pci", DeviceName: "0000:08:00.0", PortIndex: 2, PortFlavour: nl.DEVLINK_PORT_FLAVOUR_PCI_VF, NetdeviceName: "eth0", Fn: &netlink.DevlinkPortFn{State: nl.DEVLINK_PORT_FN_STATE_ACTIVE}},
+			{BusName: "pci", DeviceName: "0000:08:00.0", PortIndex: 3, PortFlavour: nl.DEVLINK_PORT_FLAVOUR_PCI_VF, NetdeviceName: "eth1", Fn: &netlink.DevlinkPortFn{State: nl.DEVLINK_PORT_FN_STATE_INACTIVE}},
+			{BusName: "pci", DeviceName: "0000:08:00.1", PortIndex: 4, PortFlavour: nl.DEVLINK_PORT_FLAVOUR_PCI_VF, NetdeviceName: "eth2", Fn: &netlink.DevlinkPortFn{State: nl.DEVLINK_PORT_FN_STATE_ACTIVE}},
+		}
+		mockNetlink.EXPECT().DevLinkGetAllPortList().DoAndReturn(func() ([]*netlink.DevlinkPort, error) {
+			return ports, nil
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	fnState := func(state uint8) netlink.DevlinkPortFnSetAttrs {
+		return netlink.DevlinkPortFnSetAttrs{FnAttrs: netlink.DevlinkPortFn{State: state}, StateValid: true}
+	}
+
+	It("should deactivate the active VFs and only reactivate those", func() {
+		mockNetlink.EXPECT().DevlinkPortFnSet("pci", "0000:08:00.0", uint32(2), fnState(nl.DEVLINK_PORT_FN_STATE_INACTIVE)).Return(nil).Times(1)
+		Expect(d.Apply(link, false)).To(Succeed())
+		Expect(d.Owned()).To(Equal(map[int]uint32{0: nl.DEVLINK_PORT_FN_STATE_ACTIVE}))
+
+		ports[1].Fn.State = nl.DEVLINK_PORT_FN_STATE_INACTIVE
+		mockNetlink.EXPECT().DevlinkPortFnSet("pci", "0000:08:00.0", uint32(2), fnState(nl.DEVLINK_PORT_FN_STATE_ACTIVE)).Return(nil).Times(1)
+		Expect(d.Apply(link, true)).To(Succeed())
+		Expect(d.Owned()).To(BeEmpty())
+	})
+
+	It("should ignore the ports of the VFs of the other PF of the device", func() {
+		representor(d.sysfs, "test", "p0")
+		representor(d.sysfs, "eth3", "pf1vf1")
+		ports = append(ports, &netlink.DevlinkPort{BusName: "pci", DeviceName: "0000:08:00.0", PortIndex: 5, PortFlavour: nl.DEVLINK_PORT_FLAVOUR_PCI_VF, NetdeviceName: "eth3", Fn: &netlink.DevlinkPortFn{State: nl.DEVLINK_PORT_FN_STATE_ACTIVE}})
+
+		mockNetlink.EXPECT().DevlinkPortFnSet("pci", "0000:08:00.0", uint32(2), fnState(nl.DEVLINK_PORT_FN_STATE_INACTIVE)).Return(nil).Times(1)
+		Expect(d.Apply(link, false)).To(Succeed())
+		Expect(d.Owned()).To(Equal(map[int]uint32{0: nl.DEVLINK_PORT_FN_STATE_ACTIVE}))
+	})
+
+	It("should keep the VFs it deactivated when their port cannot be looked up", func() {
+		mockNetlink.EXPECT().DevlinkPortFnSet("pci", "0000:08:00.0", uint32(2), fnState(nl.DEVLINK_PORT_FN_STATE_INACTIVE)).Return(nil).Times(1)
+		Expect(d.Apply(link, false)).To(Succeed())
+		ports[1].Fn.State = nl.DEVLINK_PORT_FN_STATE_INACTIVE
+
+		By("missing the port function")
+		fn := ports[1].Fn
+		ports[1].Fn = nil
+		Expect(d.Apply(link, true)).To(MatchError(ContainSubstring("port function is not supported")))
+		Expect(d.Owned()).To(Equal(map[int]uint32{0: nl.DEVLINK_PORT_FN_STATE_ACTIVE}))
+
+		By("missing the port")
+		all := ports
+		ports = all[2:]
+		Expect(d.Apply(link, true)).To(MatchError(ContainSubstring("vf 0: devlink port not found")))
+		Expect(d.Owned()).To(Equal(map[int]uint32{0: nl.DEVLINK_PORT_FN_STATE_ACTIVE}))
+
+		By("reactivating it once it is found again")
+		ports = all
+		ports[1].Fn = fn
+		mockNetlink.EXPECT().DevlinkPortFnSet("pci", "0000:08:00.0", uint32(2), fnState(nl.DEVLINK_PORT_FN_STATE_ACTIVE)).Return(nil).Times(1)
+		Expect(d.Apply(link, true)).To(Succeed())
+		Expect(d.Owned()).To(BeEmpty())
+	})
+
+	It("should restore the ownership of the VFs when the ports cannot be listed", func() {
+		d.sysfs = GinkgoT().TempDir()
+		d.Restore(link, map[int]uint32{0: nl.DEVLINK_PORT_FN_STATE_ACTIVE, 5: nl.DEVLINK_PORT_FN_STATE_ACTIVE})
+		Expect(d.Owned()).To(Equal(map[int]uint32{0: nl.DEVLINK_PORT_FN_STATE_ACTIVE}))
+	})
+
+	It("should return an error when the port of a VF is not found", func() {
+		link.Vfs = append(link.Vfs, netlink.VfInfo{ID: 2})
+		mockNetlink.EXPECT().DevlinkPortFnSet("pci", "0000:08:00.0", uint32(2), gomock.Any()).Return(errors.New("failed")).Times(1)
+
+		err := d.Apply(link, false)
+		Expect(err).To(MatchError("vf 2: devlink port not found\nvf 0: failed"))
+		Expect(d.Owned()).To(BeEmpty())
+	})
+
+	It("should be registered", func() {
+		a, err := New("devlink", "test", mockNetlink, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a).To(BeAssignableToTypeOf(&Devlink{}))
+	})
+})
@@ -0,0 +1,303 @@
+package actuator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+// ledger records the VFs disabled by an actuator along with their original state, so that only those VFs are
+// restored when the PF recovers.
+type ledger struct {
+	pf string
+	// kind is the kind of state set on VFs, as logged.
+	kind string
+	// disabled is the state of the VFs disabled by the actuator.
+	disabled uint32
+	// name returns the name of a state.
+	name func(uint32) string
+	// owned maps the id of the VFs disabled by the actuator to their original state.
+	owned map[int]uint32
+	// retry configures how states are set. States are set once and not verified by default.
+	retry Retry
+	// batch configures the queue through which states are set. States are set one at a time by default.
+	batch Batch
+	// priority orders the VFs. VFs with a higher priority are disabled first and restored last.
+	priority Priority
+	// selected tells whether the actuator disables a VF. All VFs are selected when nil. The VFs that are owned are
+	// restored even when they are no longer selected.
+	selected func(vf netlink.VfInfo) bool
+	// progress is called whenever a state was set or read back, and before every retry. It is called concurrently
+	// when states are set in parallel.
+	progress func()
+}
+
+// ProgressReporter is implemented by actuators that report their progress while they set the state of VFs, so that an
+// actuation that is long because it is retried or rate limited can be told apart from one that hangs.
+type ProgressReporter interface {
+	// SetProgress sets the function called whenever the actuator made progress.
+	SetProgress(progress func())
+}
+
+// setFunc sets the state of a VF.
+type setFunc func(id int, state uint32) error
+
+// getFunc reads the state of the VFs by id. The VFs whose state cannot be read are left out, along with an error.
+type getFunc func() (map[int]uint32, error)
+
+func newLedger(pf, kind string, disabled uint32, name func(uint32) string) ledger {
+	return ledger{pf: pf, kind: kind, disabled: disabled, name: name, owned: make(map[int]uint32), priority: (&priorities{pf: pf, sysfs: "/sys"}).of}
+}
+
+// SetRetry configures how the state of the VFs is set.
+func (l *ledger) SetRetry(retry Retry) {
+	l.retry = retry
+}
+
+// SetBatch configures the queue through which the state of the VFs is set.
+func (l *ledger) SetBatch(batch Batch) {
+	l.batch = batch
+}
+
+// SetProgress sets the function called whenever the actuator made progress.
+func (l *ledger) SetProgress(progress func()) {
+	l.progress = progress
+}
+
+// beat reports that the actuator made progress.
+func (l *ledger) beat() {
+	if l.progress != nil {
+		l.progress()
+	}
+}
+
+// SetSelector sets the function that tells whether the actuator disables a VF.
+func (l *ledger) SetSelector(selected func(vf netlink.VfInfo) bool) {
+	l.selected = selected
+}
+
+// selects returns true when the actuator disables vf.
+func (l *ledger) selects(vf netlink.VfInfo) bool {
+	if l.selected == nil {
+		return true
+	}
+
+	if !l.selected(vf) {
+		log.Log.Debug("vf is not selected", "id", vf.ID, "interface", l.pf)
+		return false
+	}
+
+	return true
+}
+
+// SetPriority sets the priority of the VFs.
+func (l *ledger) SetPriority(priority Priority) {
+	l.priority = priority
+}
+
+// run sets the state of the VFs of link through the queue, in order of priority when disabling them and in
+// reverse order when restoring them. Transient failures are retried with a bounded exponential backoff.
+func (l *ledger) run(ctx context.Context, link netlink.Link, jobs []job, restore bool, set setFunc, get getFunc) error {
+	vfs := vfsOf(link)
+	for i := range jobs {
+		vf, ok := vfs[jobs[i].id]
+		if !ok {
+			vf = netlink.VfInfo{ID: jobs[i].id}
+		}
+		jobs[i].priority = l.priority(vf)
+		if restore {
+			jobs[i].priority = -jobs[i].priority
+		}
+	}
+
+	sortJobs(jobs)
+
+	var errs []error
+	backoff := l.retry.Backoff
+	for attempt := 1; len(jobs) > 0; attempt++ {
+		failed := l.attempt(ctx, jobs, set, get)
+
+		var retried []job
+		for _, j := range jobs {
+			err, ok := failed[j.id]
+			switch {
+			case !ok:
+				log.Log.Info("vf "+l.kind+" was set", "id", j.id, "state", l.name(j.state), "interface", l.pf)
+				j.done()
+			case Permanent(err) || attempt >= l.retry.Attempts || ctx.Err() != nil:
+				errs = append(errs, fmt.Errorf("vf %d: %w", j.id, err))
+			default:
+				log.Log.Warn("failed to set vf "+l.kind+", retrying", "id", j.id, "attempt", attempt, "backoff", backoff, "interface", l.pf, "error", err)
+				retried = append(retried, j)
+			}
+		}
+		if len(retried) == 0 {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+			l.beat()
+		case <-ctx.Done():
+			for _, j := range retried {
+				errs = append(errs, fmt.Errorf("vf %d: %w", j.id, ctx.Err()))
+			}
+			return errors.Join(errs...)
+		}
+		backoff = min(2*backoff, l.retry.MaxBackoff)
+		jobs = retried
+	}
+
+	return errors.Join(errs...)
+}
+
+// attempt sets the state of the VFs of jobs once, and verifies the states that were set by reading them back with
+// a single call to get. It returns the errors of the jobs that failed by VF id.
+func (l *ledger) attempt(ctx context.Context, jobs []job, set setFunc, get getFunc) map[int]error {
+	var mu sync.Mutex
+	failed := make(map[int]error)
+	started := make(map[int]bool, len(jobs))
+	var applied []job
+
+	queued := make([]job, len(jobs))
+	for i, j := range jobs {
+		queued[i] = job{id: j.id, state: j.state, priority: j.priority, done: func() { applied = append(applied, j) }}
+	}
+	_ = run(ctx, l.batch, queued, func(id int, state uint32) error {
+		err := set(id, state)
+		l.beat()
+		mu.Lock()
+		defer mu.Unlock()
+		started[id] = true
+		if err != nil {
+			failed[id] = err
+		}
+		return err
+	})
+	for _, j := range jobs {
+		if !started[j.id] {
+			failed[j.id] = ctx.Err()
+		}
+	}
+
+	if !l.retry.Verify || len(applied) == 0 {
+		return failed
+	}
+
+	states, err := get()
+	l.beat()
+	for _, j := range applied {
+		current, ok := states[j.id]
+		switch {
+		case !ok && err != nil:
+			failed[j.id] = fmt.Errorf("failed to read %s: %w", l.kind, err)
+		case !ok:
+			failed[j.id] = fmt.Errorf("%w: %s could not be read", ErrNotApplied, l.kind)
+		case current != j.state:
+			failed[j.id] = fmt.Errorf("%w: %s is %s, expected %s", ErrNotApplied, l.kind, l.name(current), l.name(j.state))
+		}
+	}
+
+	return failed
+}
+
+// reconcile forgets the VFs that were removed from link, and those whose state was changed by someone else, which
+// becomes their new original state. states maps the id of the VFs of link to their current state. The VFs whose
+// state could not be read are kept, so that a failed lookup does not lose them.
+func (l *ledger) reconcile(link netlink.Link, states map[int]uint32) {
+	vfs := vfsOf(link)
+	for id := range l.owned {
+		if _, ok := vfs[id]; !ok {
+			delete(l.owned, id)
+			continue
+		}
+		if state, ok := states[id]; ok && state != l.disabled {
+			log.Log.Info("vf state was changed externally", "id", id, "state", l.name(state), "interface", l.pf)
+			delete(l.owned, id)
+		}
+	}
+}
+
+// vfsOf returns the VFs of link by id.
+func vfsOf(link netlink.Link) map[int]netlink.VfInfo {
+	vfs := make(map[int]netlink.VfInfo, len(link.Attrs().Vfs))
+	for _, vf := range link.Attrs().Vfs {
+		vfs[vf.ID] = vf
+	}
+
+	return vfs
+}
+
+// apply disables the selected VFs when the PF is not healthy, and brings the VFs it disabled to target otherwise.
+func (l *ledger) apply(link netlink.Link, states map[int]uint32, healthy bool, target func(original uint32) uint32, set setFunc, get getFunc) error {
+	l.reconcile(link, states)
+
+	vfs := vfsOf(link)
+	var jobs []job
+	for id, state := range states {
+		original, owned := l.owned[id]
+
+		switch {
+		case !healthy && state != l.disabled && l.selects(vfs[id]):
+			jobs = append(jobs, job{id: id, state: l.disabled, done: func() { l.owned[id] = state }})
+		case healthy && owned:
+			jobs = append(jobs, job{id: id, state: target(original), done: func() { delete(l.owned, id) }})
+		}
+	}
+
+	return l.run(context.Background(), link, jobs, healthy, set, get)
+}
+
+// Idle returns true when the actuator does not own any VF.
+func (l *ledger) Idle() bool {
+	return len(l.owned) == 0
+}
+
+// Owned returns the VFs disabled by the actuator mapped to their original state.
+func (l *ledger) Owned() map[int]uint32 {
+	return maps.Clone(l.owned)
+}
+
+// StateName returns the name of a state set by the actuator.
+func (l *ledger) StateName(state uint32) string {
+	return l.name(state)
+}
+
+// restore takes ownership of the VFs of link disabled by a previous instance that are still disabled, or whose
+// state could not be read.
+func (l *ledger) restore(link netlink.Link, states map[int]uint32, owned map[int]uint32) {
+	vfs := vfsOf(link)
+	for id, original := range owned {
+		if _, ok := vfs[id]; !ok {
+			continue
+		}
+		if state, ok := states[id]; ok && state != l.disabled {
+			log.Log.Info("vf state was changed externally", "id", id, "state", l.name(state), "interface", l.pf)
+			continue
+		}
+		l.owned[id] = original
+	}
+}
+
+// release brings every VF disabled by the actuator to target, until ctx is done. The VFs whose state could not
+// be read are left owned.
+func (l *ledger) release(ctx context.Context, link netlink.Link, states map[int]uint32, target func(original uint32) uint32, set setFunc, get getFunc) error {
+	l.reconcile(link, states)
+
+	jobs := make([]job, 0, len(l.owned))
+	for id, original := range l.owned {
+		if _, ok := states[id]; !ok {
+			continue
+		}
+		jobs = append(jobs, job{id: id, state: target(original), done: func() { delete(l.owned, id) }})
+	}
+
+	return l.run(ctx, link, jobs, true, set, get)
+}
@@ -0,0 +1,254 @@
+package actuator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/config"
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+// Priority returns the priority of a VF. VFs with a higher priority are disabled first when the PF fails, and
+// restored last when it recovers.
+type Priority func(vf netlink.VfInfo) int
+
+// Prioritizer is implemented by actuators that order the VFs they act on.
+type Prioritizer interface {
+	// SetPriority sets the priority of the VFs.
+	SetPriority(priority Priority)
+}
+
+// Priorities of the VFs that are not assigned any.
+const (
+	priorityIdle = iota
+	priorityInUse
+)
+
+// priorities assigns priorities to the VFs of a PF from its configuration.
+type priorities struct {
+	pf string
+	// sysfs is the mount point of sysfs, where the drivers and PCI addresses of the VFs are read from.
+	sysfs   string
+	ids     map[int]int
+	macs    map[string]int
+	kubelet *kubelet
+}
+
+// NewPriority returns the priority of the VFs of pf assigned by conf. VFs that are not assigned any are ordered
+// by their usage by pods.
+func NewPriority(pf string, conf config.Priority) (Priority, error) {
+	p, err := newPriorities(pf, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.of, nil
+}
+
+func newPriorities(pf string, conf config.Priority) (*priorities, error) {
+	p := &priorities{pf: pf, sysfs: "/sys", ids: make(map[int]int), macs: make(map[string]int)}
+
+	for raw, priority := range conf.IDs {
+		ids, err := parseIDs(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vf priority: %w", err)
+		}
+		for id := range ids {
+			if current, ok := p.ids[id]; !ok || priority > current {
+				p.ids[id] = priority
+			}
+		}
+	}
+
+	for raw, priority := range conf.MACs {
+		mac, err := net.ParseMAC(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vf priority: %w", err)
+		}
+		// The same MAC can be written in several ways, the highest priority wins.
+		if current, ok := p.macs[mac.String()]; !ok || priority > current {
+			p.macs[mac.String()] = priority
+		}
+	}
+
+	if conf.Kubelet != nil {
+		p.kubelet = &kubelet{path: conf.Kubelet.Checkpoint, priority: conf.Kubelet.Priority, resources: conf.Kubelet.Resources}
+		if p.kubelet.path == "" {
+			p.kubelet.path = config.DefaultKubeletCheckpoint
+		}
+	}
+
+	return p, nil
+}
+
+// of returns the highest priority assigned to vf, or its usage when it is not assigned any.
+func (p *priorities) of(vf netlink.VfInfo) int {
+	var assigned []int
+	if priority, ok := p.ids[vf.ID]; ok {
+		assigned = append(assigned, priority)
+	}
+	if priority, ok := p.macs[vf.Mac.String()]; ok && len(vf.Mac) > 0 {
+		assigned = append(assigned, priority)
+	}
+	if p.kubelet != nil {
+		if priority, ok := p.kubelet.of(p.address(vf.ID)); ok {
+			assigned = append(assigned, priority)
+		}
+	}
+
+	if len(assigned) == 0 {
+		return p.usage(vf.ID)
+	}
+
+	return slices.Max(assigned)
+}
+
+// virtfn returns the sysfs directory of a VF.
+func (p *priorities) virtfn(id int) string {
+	return filepath.Join(p.sysfs, "class", "net", p.pf, "device", "virtfn"+strconv.Itoa(id))
+}
+
+// address returns the PCI address of a VF, or an empty string if it is not found.
+func (p *priorities) address(id int) string {
+	target, err := os.Readlink(p.virtfn(id))
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Base(target)
+}
+
+// usage returns the priority of a VF from its usage by pods: VFs bound to vfio-pci, or whose network interface
+// was moved out of the namespace of the relay, are used by pods and come first.
+func (p *priorities) usage(id int) int {
+	target, err := os.Readlink(filepath.Join(p.virtfn(id), "driver"))
+	if err != nil {
+		return priorityIdle
+	}
+	if filepath.Base(target) == "vfio-pci" {
+		return priorityInUse
+	}
+
+	// VFs bound to a driver that does not expose a network interface are used by DPDK.
+	exposed, inNamespace := netdevOf(p.virtfn(id))
+	if !exposed || !inNamespace {
+		return priorityInUse
+	}
+
+	return priorityIdle
+}
+
+// kubelet assigns a priority to the devices allocated to pods, read from the checkpoint of the device manager of
+// kubelet. The checkpoint is read again when it changes.
+type kubelet struct {
+	path      string
+	priority  int
+	resources map[string]int
+
+	mu      sync.Mutex
+	modTime time.Time
+	// devices maps the id of the devices allocated to pods, which are PCI addresses for SR-IOV, to their resource.
+	devices map[string]string
+	err     error
+}
+
+// checkpoint is the part of the checkpoint of the device manager of kubelet that is read.
+type checkpoint struct {
+	Data struct {
+		PodDeviceEntries []struct {
+			ResourceName string `json:"ResourceName"`
+			// DeviceIDs maps NUMA nodes to device ids since Kubernetes 1.20, and is a list of device ids before.
+			DeviceIDs json.RawMessage `json:"DeviceIDs"`
+		} `json:"PodDeviceEntries"`
+	} `json:"Data"`
+}
+
+// of returns the priority of the device with the given id, and whether it is allocated to a pod.
+func (k *kubelet) of(id string) (int, bool) {
+	if id == "" {
+		return 0, false
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.load()
+	resource, ok := k.devices[id]
+	if !ok {
+		return 0, false
+	}
+	if priority, ok := k.resources[resource]; ok {
+		return priority, true
+	}
+
+	return k.priority, true
+}
+
+// load reads the checkpoint when it changed. The previous devices are kept when it cannot be read.
+func (k *kubelet) load() {
+	info, err := os.Stat(k.path)
+	if errors.Is(err, os.ErrNotExist) {
+		k.devices, k.modTime = nil, time.Time{}
+		return
+	}
+	if err == nil && info.ModTime().Equal(k.modTime) {
+		return
+	}
+
+	var devices map[string]string
+	if err == nil {
+		devices, err = readCheckpoint(k.path)
+	}
+	if err != nil {
+		if k.err == nil || k.err.Error() != err.Error() {
+			log.Log.Warn("failed to read kubelet checkpoint", "path", k.path, "error", err)
+		}
+		k.err = err
+		return
+	}
+
+	k.devices, k.modTime, k.err = devices, info.ModTime(), nil
+}
+
+// readCheckpoint returns the devices allocated to pods mapped to their resource.
+func readCheckpoint(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c checkpoint
+	err = json.Unmarshal(raw, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make(map[string]string)
+	for _, e := range c.Data.PodDeviceEntries {
+		var ids []string
+		var numa map[string][]string
+		if json.Unmarshal(e.DeviceIDs, &numa) == nil {
+			for _, node := range numa {
+				ids = append(ids, node...)
+			}
+		} else if err := json.Unmarshal(e.DeviceIDs, &ids); err != nil {
+			return nil, fmt.Errorf("invalid device ids of %s: %w", e.ResourceName, err)
+		}
+
+		for _, id := range ids {
+			devices[id] = e.ResourceName
+		}
+	}
+
+	return devices, nil
+}
@@ -0,0 +1,161 @@
+package actuator
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/pf-status-relay/pkg/config"
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+var _ = Describe("Priority", func() {
+	var sysfs string
+
+	// bind creates the sysfs entries of a VF with the given PCI address bound to driver, with a network
+	// interface named netdev in the namespace of the relay unless empty.
+	bind := func(id, address, driver, netdev string) {
+		device := filepath.Join(sysfs, "class", "net", "test", "device")
+		Expect(os.MkdirAll(filepath.Join(sysfs, "devices", address, "net", netdev), 0o755)).To(Succeed())
+		Expect(os.MkdirAll(device, 0o755)).To(Succeed())
+		Expect(os.Symlink(filepath.Join(sysfs, "devices", address), filepath.Join(device, "virtfn"+id))).To(Succeed())
+		Expect(os.Symlink("../../../bus/pci/drivers/"+driver, filepath.Join(sysfs, "devices", address, "driver"))).To(Succeed())
+	}
+
+	newTestPriorities := func(conf config.Priority) *priorities {
+		p, err := newPriorities("test", conf)
+		Expect(err).NotTo(HaveOccurred())
+		p.sysfs = sysfs
+		return p
+	}
+
+	BeforeEach(func() {
+		sysfs = GinkgoT().TempDir()
+		// VF 0 is in the namespace of the relay, VF 1 is used by a DPDK pod, VF 2 was moved into a pod and VF 3 is
+		// not bound.
+		bind("0", "0000:3b:02.0", "iavf", "eth0")
+		bind("1", "0000:3b:02.1", "vfio-pci", "")
+		bind("2", "0000:3b:02.2", "iavf", "")
+	})
+
+	It("should order the VFs that are not assigned a priority by their usage by pods", func() {
+		p := newTestPriorities(config.Priority{})
+		Expect(p.of(netlink.VfInfo{ID: 0})).To(Equal(priorityIdle))
+		Expect(p.of(netlink.VfInfo{ID: 1})).To(Equal(priorityInUse))
+		Expect(p.of(netlink.VfInfo{ID: 2})).To(Equal(priorityInUse))
+		Expect(p.of(netlink.VfInfo{ID: 3})).To(Equal(priorityIdle))
+	})
+
+	It("should assign the highest priority of the static sources", func() {
+		p := newTestPriorities(config.Priority{
+			IDs:  map[string]int{"0-1": 10, "1": 20},
+			MACs: map[string]int{"02:00:00:00:00:10": 30},
+		})
+		Expect(p.of(netlink.VfInfo{ID: 0})).To(Equal(10))
+		Expect(p.of(netlink.VfInfo{ID: 1})).To(Equal(20))
+		Expect(p.of(netlink.VfInfo{ID: 0, Mac: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10}})).To(Equal(30))
+		// A VF that is not assigned a priority falls back to its usage, even if its MAC is not set.
+		Expect(p.of(netlink.VfInfo{ID: 2, Mac: net.HardwareAddr{}})).To(Equal(priorityInUse))
+	})
+
+	It("should keep negative priorities", func() {
+		p := newTestPriorities(config.Priority{
+			IDs:  map[string]int{"0": -10, "1-2": -20, "2": -5},
+			MACs: map[string]int{"02:00:00:00:00:10": -30, "02-00-00-00-00-10": -40},
+		})
+		Expect(p.of(netlink.VfInfo{ID: 0})).To(Equal(-10))
+		Expect(p.of(netlink.VfInfo{ID: 1})).To(Equal(-20))
+		Expect(p.of(netlink.VfInfo{ID: 2})).To(Equal(-5))
+		Expect(p.of(netlink.VfInfo{ID: 3, Mac: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10}})).To(Equal(-30))
+	})
+
+	It("should return an error for invalid static sources", func() {
+		_, err := NewPriority("test", config.Priority{IDs: map[string]int{"3-1": 10}})
+		Expect(err).To(MatchError(`invalid vf priority: invalid vf id range "3-1"`))
+
+		_, err = NewPriority("test", config.Priority{MACs: map[string]int{"invalid": 10}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("kubelet", func() {
+		var checkpoint string
+
+		write := func(content string, modTime time.Time) {
+			Expect(os.WriteFile(checkpoint, []byte(content), 0o600)).To(Succeed())
+			Expect(os.Chtimes(checkpoint, modTime, modTime)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			checkpoint = filepath.Join(GinkgoT().TempDir(), "kubelet_internal_checkpoint")
+		})
+
+		It("should assign a priority to the VFs allocated to pods", func() {
+			write(`{"Data":{"PodDeviceEntries":[
+{"PodUID":"a","ContainerName":"c","ResourceName":"openshift.io/prod","DeviceIDs":{"0":["0000:3b:02.0"]}},
+{"PodUID":"b","ContainerName":"c","ResourceName":"openshift.io/test","DeviceIDs":{"-1":["0000:3b:02.2"]}}
+],"RegisteredDevices":{}},"Checksum":1}`, time.Unix(1, 0))
+
+			p := newTestPriorities(config.Priority{Kubelet: &config.KubeletPriority{
+				Checkpoint: checkpoint,
+				Priority:   10,
+				Resources:  map[string]int{"openshift.io/prod": 100},
+			}})
+			Expect(p.of(netlink.VfInfo{ID: 0})).To(Equal(100))
+			Expect(p.of(netlink.VfInfo{ID: 1})).To(Equal(priorityInUse))
+			Expect(p.of(netlink.VfInfo{ID: 2})).To(Equal(10))
+
+			By("reading the checkpoint again when it changes")
+			write(`{"Data":{"PodDeviceEntries":[
+{"PodUID":"b","ContainerName":"c","ResourceName":"openshift.io/test","DeviceIDs":["0000:3b:02.1"]}
+]},"Checksum":2}`, time.Unix(2, 0))
+			Expect(p.of(netlink.VfInfo{ID: 0})).To(Equal(priorityIdle))
+			Expect(p.of(netlink.VfInfo{ID: 1})).To(Equal(10))
+
+			By("keeping the devices when the checkpoint cannot be read")
+			write(`{`, time.Unix(3, 0))
+			Expect(p.of(netlink.VfInfo{ID: 1})).To(Equal(10))
+
+			By("forgetting the devices when the checkpoint is removed")
+			Expect(os.Remove(checkpoint)).To(Succeed())
+			Expect(p.of(netlink.VfInfo{ID: 1})).To(Equal(priorityInUse))
+		})
+	})
+
+	Describe("VfState", func() {
+		It("should disable the VFs by priority and restore them in reverse order", func() {
+			ctrl := gomock.NewController(GinkgoT())
+			mockNetlink := interfaces.NewMockNetlink(ctrl)
+			link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test"}}
+			for id := range 4 {
+				link.Vfs = append(link.Vfs, netlink.VfInfo{ID: id, LinkState: netlink.VF_LINK_STATE_AUTO})
+			}
+
+			var order []int
+			mockNetlink.EXPECT().LinkSetVfState(link, gomock.Any(), gomock.Any()).DoAndReturn(func(_ netlink.Link, id int, _ uint32) error {
+				order = append(order, id)
+				return nil
+			}).Times(8)
+
+			v := NewVfState("test", mockNetlink)
+			priority, err := NewPriority("test", config.Priority{IDs: map[string]int{"2": 20, "1": 10}})
+			Expect(err).NotTo(HaveOccurred())
+			v.SetPriority(priority)
+
+			Expect(v.Apply(link, false)).To(Succeed())
+			Expect(order).To(Equal([]int{2, 1, 0, 3}))
+
+			for i := range link.Vfs {
+				link.Vfs[i].LinkState = netlink.VF_LINK_STATE_DISABLE
+			}
+			order = nil
+			Expect(v.Apply(link, true)).To(Succeed())
+			Expect(order).To(Equal([]int{0, 3, 1, 2}))
+		})
+	})
+})
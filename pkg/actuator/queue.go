@@ -0,0 +1,100 @@
+package actuator
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Batch configures how many VF states of a PF are set at once, for NICs whose firmware throttles the mailbox
+// commands issued to VFs.
+type Batch struct {
+	// Concurrency is the number of VF states set in parallel. They are set one at a time when lower than 2.
+	Concurrency int
+	// Rate is the maximum number of VF states set per second. It is not limited when 0.
+	Rate float64
+}
+
+// Batcher is implemented by actuators that set the state of VFs through a queue.
+type Batcher interface {
+	// SetBatch configures the queue of the actuator.
+	SetBatch(batch Batch)
+}
+
+// job sets the state of a VF.
+type job struct {
+	id    int
+	state uint32
+	// priority orders jobs, higher first.
+	priority int
+	// done is called once every job ran when the state was set.
+	done func()
+}
+
+// run runs the jobs in order of priority through the queue configured by batch, and returns their errors. Jobs
+// that are not started when ctx is done fail with its error. The callbacks of the jobs are called from the calling
+// goroutine, in order.
+func run(ctx context.Context, batch Batch, jobs []job, set setFunc) error {
+	sortJobs(jobs)
+
+	var interval time.Duration
+	if batch.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / batch.Rate)
+	}
+
+	results := make([]error, len(jobs))
+	sem := make(chan struct{}, max(batch.Concurrency, 1))
+	var wg sync.WaitGroup
+	var next time.Time
+	for i, j := range jobs {
+		if wait := time.Until(next); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+			}
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = ctx.Err()
+			continue
+		}
+		if ctx.Err() != nil {
+			<-sem
+			results[i] = ctx.Err()
+			continue
+		}
+		next = time.Now().Add(interval)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = set(j.id, j.state)
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, j := range jobs {
+		if results[i] != nil {
+			errs = append(errs, fmt.Errorf("vf %d: %w", j.id, results[i]))
+			continue
+		}
+		j.done()
+	}
+
+	return errors.Join(errs...)
+}
+
+// sortJobs sorts jobs by priority, higher first, then by VF id.
+func sortJobs(jobs []job) {
+	slices.SortStableFunc(jobs, func(a, b job) int {
+		return cmp.Or(cmp.Compare(b.priority, a.priority), cmp.Compare(a.id, b.id))
+	})
+}
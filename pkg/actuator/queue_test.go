@@ -0,0 +1,122 @@
+package actuator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+var _ = Describe("Queue", func() {
+	jobs := func(ids ...int) []job {
+		var jobs []job
+		for _, id := range ids {
+			jobs = append(jobs, job{id: id, done: func() {}})
+		}
+		return jobs
+	}
+
+	It("should run jobs by priority, then by id", func() {
+		var mu sync.Mutex
+		var order []int
+		j := jobs(3, 0, 2, 1)
+		j[2].priority = priorityInUse
+
+		err := run(context.Background(), Batch{}, j, func(id int, _ uint32) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, id)
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(order).To(Equal([]int{2, 0, 1, 3}))
+	})
+
+	It("should not run more jobs than the concurrency at once", func() {
+		var running, peak atomic.Int32
+		err := run(context.Background(), Batch{Concurrency: 3}, jobs(0, 1, 2, 3, 4, 5, 6, 7, 8, 9), func(int, uint32) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(peak.Load()).To(Equal(int32(3)))
+	})
+
+	It("should limit the rate of jobs", func() {
+		start := time.Now()
+		err := run(context.Background(), Batch{Concurrency: 5, Rate: 100}, jobs(0, 1, 2, 3, 4), func(int, uint32) error {
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically(">=", 40*time.Millisecond))
+	})
+
+	It("should only call back the jobs that succeeded and join errors in order", func() {
+		var done []int
+		j := jobs(0, 1, 2)
+		for i := range j {
+			j[i].done = func() { done = append(done, j[i].id) }
+		}
+
+		err := run(context.Background(), Batch{Concurrency: 2}, j, func(id int, _ uint32) error {
+			if id != 1 {
+				return errors.New("failed")
+			}
+			return nil
+		})
+		Expect(err).To(MatchError("vf 0: failed\nvf 2: failed"))
+		Expect(done).To(Equal([]int{1}))
+	})
+
+	It("should fail the jobs that are not started when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		err := run(ctx, Batch{}, jobs(0, 1), func(int, uint32) error {
+			cancel()
+			return nil
+		})
+		Expect(err).To(MatchError("vf 1: context canceled"))
+	})
+
+	Describe("VfState", func() {
+		It("should set the VFs used by pods first", func() {
+			ctrl := gomock.NewController(GinkgoT())
+			mockNetlink := interfaces.NewMockNetlink(ctrl)
+			link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test"}}
+			for id := range 8 {
+				link.Vfs = append(link.Vfs, netlink.VfInfo{ID: id, LinkState: netlink.VF_LINK_STATE_AUTO})
+			}
+
+			var mu sync.Mutex
+			var order []int
+			mockNetlink.EXPECT().LinkSetVfState(link, gomock.Any(), uint32(netlink.VF_LINK_STATE_DISABLE)).DoAndReturn(func(_ netlink.Link, id int, _ uint32) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, id)
+				return nil
+			}).Times(8)
+
+			v := NewVfState("test", mockNetlink)
+			v.priority = func(vf netlink.VfInfo) int {
+				if vf.ID == 5 {
+					return priorityInUse
+				}
+				return priorityIdle
+			}
+			Expect(v.Apply(link, false)).To(Succeed())
+			Expect(order).To(Equal([]int{5, 0, 1, 2, 3, 4, 6, 7}))
+			Expect(v.Owned()).To(HaveLen(8))
+		})
+	})
+})
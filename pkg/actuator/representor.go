@@ -0,0 +1,181 @@
+package actuator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+func init() {
+	Register("representor", func(pf string, nl interfaces.Netlink, _ map[string]string) (Actuator, error) {
+		return NewRepresentor(pf, nl), nil
+	})
+}
+
+const (
+	representorDown uint32 = iota
+	representorUp
+)
+
+// Representor relays the health of a PF in switchdev mode by setting the representors of its VFs
+// administratively down, which stops the offloaded datapath. Like VfState, it only brings up the representors
+// it set down.
+type Representor struct {
+	ledger
+	nl interfaces.Netlink
+	// sysfs is the mount point of sysfs, where switch ids and physical port names are read from.
+	sysfs string
+}
+
+// NewRepresentor returns a Representor actuator for the given PF.
+func NewRepresentor(pf string, nl interfaces.Netlink) *Representor {
+	return &Representor{ledger: newLedger(pf, "representor state", representorDown, representorStateName), nl: nl, sysfs: "/sys"}
+}
+
+// Name returns the name of the actuator.
+func (r *Representor) Name() string {
+	return "representor"
+}
+
+// Apply sets the representors of the VFs down when the PF is not healthy, and brings up the ones it set down
+// otherwise.
+func (r *Representor) Apply(link netlink.Link, healthy bool) error {
+	states, links, err := r.states(link)
+	return errors.Join(err, r.apply(link, states, healthy, original, r.setter(links), r.getter(link)))
+}
+
+// Restore takes ownership of the representors set down by a previous instance that are still down.
+func (r *Representor) Restore(link netlink.Link, owned map[int]uint32) {
+	states, _, err := r.states(link)
+	if err != nil {
+		log.Log.Warn("failed to find vf representors", "interface", r.pf, "error", err)
+	}
+	r.restore(link, states, owned)
+}
+
+// Release brings up every representor set down by the actuator.
+func (r *Representor) Release(ctx context.Context, link netlink.Link) error {
+	states, links, err := r.states(link)
+	return errors.Join(err, r.release(ctx, link, states, original, r.setter(links), r.getter(link)))
+}
+
+// representors returns the names of the representors of the VFs of the PF by VF id. Representors share the
+// switch id of the PF, and their physical port name identifies the VF (i.e. "pf0vf3").
+func (r *Representor) representors() (map[int]string, error) {
+	switchID, err := r.switchID(r.pf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read switch id: %w", err)
+	}
+
+	number := pfNumber(r.sysfs, r.pf)
+
+	entries, err := os.ReadDir(filepath.Join(r.sysfs, "class", "net"))
+	if err != nil {
+		return nil, err
+	}
+
+	representors := make(map[int]string)
+	for _, e := range entries {
+		name := e.Name()
+		if name == r.pf {
+			continue
+		}
+		if id, err := r.switchID(name); err != nil || id != switchID {
+			continue
+		}
+
+		pf, vf, ok := represented(r.sysfs, name)
+		if !ok || (number >= 0 && pf != number) {
+			continue
+		}
+		representors[vf] = name
+	}
+
+	return representors, nil
+}
+
+// switchID returns the switch id of an interface.
+func (r *Representor) switchID(name string) (string, error) {
+	raw, err := os.ReadFile(filepath.Join(r.sysfs, "class", "net", name, "phys_switch_id"))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(raw)), nil
+}
+
+// states returns the administrative state of the representors of the VFs of link, along with their links.
+func (r *Representor) states(link netlink.Link) (map[int]uint32, map[int]netlink.Link, error) {
+	representors, err := r.representors()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var errs []error
+	states := make(map[int]uint32, len(link.Attrs().Vfs))
+	links := make(map[int]netlink.Link, len(link.Attrs().Vfs))
+	for _, vf := range link.Attrs().Vfs {
+		name, ok := representors[vf.ID]
+		if !ok {
+			errs = append(errs, fmt.Errorf("vf %d: representor not found", vf.ID))
+			continue
+		}
+
+		rep, err := r.nl.LinkByName(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("vf %d: %w", vf.ID, err))
+			continue
+		}
+
+		states[vf.ID] = representorState(rep)
+		links[vf.ID] = rep
+	}
+
+	return states, links, errors.Join(errs...)
+}
+
+// setter returns a function that sets the administrative state of the representor of a VF.
+func (r *Representor) setter(links map[int]netlink.Link) setFunc {
+	return func(id int, state uint32) error {
+		if state == representorUp {
+			return r.nl.LinkSetUp(links[id])
+		}
+
+		return r.nl.LinkSetDown(links[id])
+	}
+}
+
+// getter returns a function that reads the administrative state of the representors of the VFs of link.
+func (r *Representor) getter(link netlink.Link) getFunc {
+	return func() (map[int]uint32, error) {
+		states, _, err := r.states(link)
+		return states, err
+	}
+}
+
+// representorState returns the administrative state of a representor.
+func representorState(rep netlink.Link) uint32 {
+	if rep.Attrs().Flags&net.FlagUp != 0 {
+		return representorUp
+	}
+
+	return representorDown
+}
+
+// representorStateName returns the name of an administrative state.
+func representorStateName(state uint32) string {
+	if state == representorUp {
+		return "up"
+	}
+
+	return "down"
+}
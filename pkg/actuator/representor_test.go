@@ -0,0 +1,128 @@
+package actuator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+var _ = Describe("Representor", func() {
+	var (
+		ctrl        *gomock.Controller
+		mockNetlink *interfaces.MockNetlink
+		r           *Representor
+		link        *netlink.Dummy
+		reps        map[string]*netlink.Dummy
+		failing     map[string]bool
+	)
+
+	// netdev creates the sysfs entries of an interface in switchdev mode.
+	netdev := func(sysfs, name, switchID, physPortName string) {
+		dir := filepath.Join(sysfs, "class", "net", name)
+		Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "phys_switch_id"), []byte(switchID+"\n"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "phys_port_name"), []byte(physPortName+"\n"), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockNetlink = interfaces.NewMockNetlink(ctrl)
+
+		sysfs := GinkgoT().TempDir()
+		netdev(sysfs, "test", "1122334455", "p0")
+		netdev(sysfs, "eth0", "1122334455", "pf0vf0")
+		netdev(sysfs, "eth1", "1122334455", "pf0vf1")
+		// Representors of another PF or switch are ignored.
+		netdev(sysfs, "eth2", "1122334455", "pf1vf0")
+		netdev(sysfs, "eth3", "5544332211", "pf0vf0")
+
+		r = NewRepresentor("test", mockNetlink)
+		r.sysfs = sysfs
+
+		link = &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test", Vfs: []netlink.VfInfo{{ID: 0}, {ID: 1}}}}
+		reps = map[string]*netlink.Dummy{
+			"eth0": {LinkAttrs: netlink.LinkAttrs{Name: "eth0", Flags: net.FlagUp}},
+			"eth1": {LinkAttrs: netlink.LinkAttrs{Name: "eth1"}},
+		}
+		failing = make(map[string]bool)
+		mockNetlink.EXPECT().LinkByName(gomock.Any()).DoAndReturn(func(name string) (netlink.Link, error) {
+			if failing[name] {
+				return nil, errors.New("lookup failed")
+			}
+			return reps[name], nil
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("should set the representors down and only bring up those", func() {
+		mockNetlink.EXPECT().LinkSetDown(reps["eth0"]).Return(nil).Times(1)
+		Expect(r.Apply(link, false)).To(Succeed())
+		Expect(r.Owned()).To(Equal(map[int]uint32{0: representorUp}))
+
+		reps["eth0"].Flags = 0
+		mockNetlink.EXPECT().LinkSetUp(reps["eth0"]).Return(nil).Times(1)
+		Expect(r.Apply(link, true)).To(Succeed())
+		Expect(r.Owned()).To(BeEmpty())
+	})
+
+	It("should forget representors brought up by someone else", func() {
+		mockNetlink.EXPECT().LinkSetDown(reps["eth0"]).Return(nil).Times(1)
+		Expect(r.Apply(link, false)).To(Succeed())
+
+		Expect(r.Apply(link, true)).To(Succeed())
+		Expect(r.Owned()).To(BeEmpty())
+	})
+
+	It("should keep the representors it set down when they cannot be looked up", func() {
+		mockNetlink.EXPECT().LinkSetDown(reps["eth0"]).Return(nil).Times(1)
+		Expect(r.Apply(link, false)).To(Succeed())
+		reps["eth0"].Flags = 0
+
+		By("failing to look up the representor")
+		failing["eth0"] = true
+		Expect(r.Apply(link, true)).To(MatchError(ContainSubstring("lookup failed")))
+		Expect(r.Owned()).To(Equal(map[int]uint32{0: representorUp}))
+
+		By("failing to list the representors")
+		sysfs := r.sysfs
+		r.sysfs = GinkgoT().TempDir()
+		Expect(r.Apply(link, true)).NotTo(Succeed())
+		Expect(r.Release(context.Background(), link)).NotTo(Succeed())
+		Expect(r.Owned()).To(Equal(map[int]uint32{0: representorUp}))
+
+		By("bringing it up once it is found again")
+		r.sysfs = sysfs
+		failing["eth0"] = false
+		mockNetlink.EXPECT().LinkSetUp(reps["eth0"]).Return(nil).Times(1)
+		Expect(r.Apply(link, true)).To(Succeed())
+		Expect(r.Owned()).To(BeEmpty())
+	})
+
+	It("should forget the representors of VFs that were removed", func() {
+		mockNetlink.EXPECT().LinkSetDown(reps["eth0"]).Return(nil).Times(1)
+		Expect(r.Apply(link, false)).To(Succeed())
+
+		link.Vfs = link.Vfs[1:]
+		Expect(r.Apply(link, true)).To(Succeed())
+		Expect(r.Owned()).To(BeEmpty())
+	})
+
+	It("should return an error when a representor is not found", func() {
+		link.Vfs = append(link.Vfs, netlink.VfInfo{ID: 2})
+		mockNetlink.EXPECT().LinkSetDown(reps["eth0"]).Return(nil).Times(1)
+
+		Expect(r.Apply(link, false)).To(MatchError("vf 2: representor not found"))
+	})
+})
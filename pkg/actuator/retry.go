@@ -0,0 +1,43 @@
+package actuator
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrNotApplied is returned when the state of a VF read back after setting it does not match.
+var ErrNotApplied = errors.New("state was not applied")
+
+// Retry configures how the state of VFs is set.
+type Retry struct {
+	// Attempts is the maximum number of times the state is set. It is set once when lower than 1.
+	Attempts int
+	// Backoff is the delay before the first retry. It is doubled after every retry, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Verify reads back the state of the VFs after setting them, all at once.
+	Verify bool
+}
+
+// Retrier is implemented by actuators that can retry and verify the states they set.
+type Retrier interface {
+	// SetRetry configures how the state of VFs is set.
+	SetRetry(retry Retry)
+}
+
+// permanentErrors are the errors that retrying cannot fix.
+var permanentErrors = []error{unix.EOPNOTSUPP, unix.EINVAL, unix.EPERM, unix.EACCES, unix.ENODEV, unix.ENOENT, unix.ERANGE}
+
+// Permanent returns true when err cannot be fixed by retrying, i.e. the driver does not support the operation.
+// Other errors, including states that were not applied, are considered transient.
+func Permanent(err error) bool {
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return true
+		}
+	}
+
+	return false
+}
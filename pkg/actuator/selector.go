@@ -0,0 +1,253 @@
+package actuator
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/config"
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+// netdev matches VFs bound to a driver that exposes a network interface.
+const netdev = "netdev"
+
+// Selector selects the VFs of a PF that actuators act on.
+type Selector struct {
+	pf      string
+	include []rule
+	exclude []rule
+	// sysfs is the mount point of sysfs, where the drivers bound to the VFs are read from.
+	sysfs string
+}
+
+// rule is a parsed config.VFRule.
+type rule struct {
+	ids    map[int]bool
+	mac    net.HardwareAddr
+	vlan   *int
+	trust  *bool
+	driver string
+}
+
+// NewSelector returns a selector for the VFs of pf.
+func NewSelector(pf string, conf config.VFSelection) (*Selector, error) {
+	s := &Selector{pf: pf, sysfs: "/sys"}
+
+	for _, r := range conf.Include {
+		parsed, err := parseRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vf include rule: %w", err)
+		}
+		s.include = append(s.include, parsed)
+	}
+
+	for _, r := range conf.Exclude {
+		parsed, err := parseRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vf exclude rule: %w", err)
+		}
+		s.exclude = append(s.exclude, parsed)
+	}
+
+	return s, nil
+}
+
+func parseRule(r config.VFRule) (rule, error) {
+	parsed := rule{vlan: r.VLAN, trust: r.Trust, driver: r.Driver}
+	if r.IDs == "" && r.MAC == "" && r.VLAN == nil && r.Trust == nil && r.Driver == "" {
+		return parsed, errors.New("rule is empty")
+	}
+
+	if r.IDs != "" {
+		ids, err := parseIDs(r.IDs)
+		if err != nil {
+			return parsed, err
+		}
+		parsed.ids = ids
+	}
+
+	if r.MAC != "" {
+		mac, err := net.ParseMAC(r.MAC)
+		if err != nil {
+			return parsed, err
+		}
+		parsed.mac = mac
+	}
+
+	return parsed, nil
+}
+
+// parseIDs parses a comma separated list of indexes and ranges.
+func parseIDs(raw string) (map[int]bool, error) {
+	ids := make(map[int]bool)
+	for _, e := range strings.Split(raw, ",") {
+		e = strings.TrimSpace(e)
+		first, last, isRange := strings.Cut(e, "-")
+
+		start, err := strconv.Atoi(first)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid vf id %q", e)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(last)
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid vf id range %q", e)
+			}
+		}
+
+		for id := start; id <= end; id++ {
+			ids[id] = true
+		}
+	}
+
+	return ids, nil
+}
+
+// Selected returns true when the actuators must act on vf.
+func (s *Selector) Selected(vf netlink.VfInfo) bool {
+	selected := len(s.include) == 0
+	for _, r := range s.include {
+		if s.matches(r, vf) {
+			selected = true
+			break
+		}
+	}
+
+	for _, r := range s.exclude {
+		if s.matches(r, vf) {
+			return false
+		}
+	}
+
+	return selected
+}
+
+func (s *Selector) matches(r rule, vf netlink.VfInfo) bool {
+	switch {
+	case r.ids != nil && !r.ids[vf.ID]:
+		return false
+	case r.mac != nil && r.mac.String() != vf.Mac.String():
+		return false
+	case r.vlan != nil && *r.vlan != vf.Vlan:
+		return false
+	case r.trust != nil && *r.trust != (vf.Trust != 0):
+		return false
+	case r.driver != "" && !s.boundTo(r.driver, vf.ID):
+		return false
+	}
+
+	return true
+}
+
+// boundTo returns true when the VF is bound to driver, or to any driver exposing a network interface if driver
+// is netdev.
+func (s *Selector) boundTo(driver string, id int) bool {
+	virtfn := filepath.Join(s.sysfs, "class", "net", s.pf, "device", "virtfn"+strconv.Itoa(id))
+	target, err := os.Readlink(filepath.Join(virtfn, "driver"))
+	if err != nil {
+		return false
+	}
+
+	if driver == netdev {
+		exposed, _ := netdevOf(virtfn)
+		return exposed
+	}
+
+	return filepath.Base(target) == driver
+}
+
+// netdevOf returns whether the driver of the VF with the given virtfn directory exposes a network interface, and
+// whether that interface is in the namespace of the relay. The net directory of a VF whose interface was moved to
+// another namespace, i.e. of a pod, exists but is empty.
+func netdevOf(virtfn string) (bool, bool) {
+	entries, err := os.ReadDir(filepath.Join(virtfn, "net"))
+	if err != nil {
+		return false, false
+	}
+
+	return true, len(entries) > 0
+}
+
+// Selectable is implemented by actuators that select the VFs they act on by themselves, so that they still see
+// the VFs that are no longer selected and restore those they own.
+type Selectable interface {
+	// SetSelector sets the function that tells whether the actuator must act on a VF.
+	SetSelector(selected func(vf netlink.VfInfo) bool)
+}
+
+// Select returns an actuator that only acts on the VFs chosen by s. Every actuator of a chain is selected apart.
+func Select(a Actuator, s *Selector) Actuator {
+	if c, ok := a.(Chain); ok {
+		selected := make(Chain, 0, len(c))
+		for _, a := range c {
+			selected = append(selected, Select(a, s))
+		}
+		return selected
+	}
+
+	if sa, ok := a.(Selectable); ok {
+		sa.SetSelector(s.Selected)
+	}
+
+	return &selected{Actuator: a, selector: s}
+}
+
+// Selected returns true when a acts on vf.
+func Selected(a Actuator, vf netlink.VfInfo) bool {
+	switch a := a.(type) {
+	case Chain:
+		for _, c := range a {
+			if !Selected(c, vf) {
+				return false
+			}
+		}
+	case *selected:
+		return a.selector.Selected(vf)
+	}
+
+	return true
+}
+
+// selected is an actuator that hides the VFs which are not selected from actuators that are not Selectable.
+type selected struct {
+	Actuator
+	selector *Selector
+}
+
+// Apply relays the health of the PF to the selected VFs.
+func (s *selected) Apply(link netlink.Link, healthy bool) error {
+	if _, ok := s.Actuator.(Selectable); ok {
+		return s.Actuator.Apply(link, healthy)
+	}
+
+	attrs := *link.Attrs()
+	attrs.Vfs = nil
+	for _, vf := range link.Attrs().Vfs {
+		if !s.selector.Selected(vf) {
+			log.Log.Debug("vf is not selected", "id", vf.ID, "interface", s.selector.pf)
+			continue
+		}
+		attrs.Vfs = append(attrs.Vfs, vf)
+	}
+
+	return s.Actuator.Apply(&vfsLink{Link: link, attrs: attrs}, healthy)
+}
+
+// vfsLink is a link whose attributes list a subset of its VFs.
+type vfsLink struct {
+	netlink.Link
+	attrs netlink.LinkAttrs
+}
+
+// Attrs returns the attributes of the link.
+func (l *vfsLink) Attrs() *netlink.LinkAttrs {
+	return &l.attrs
+}
@@ -0,0 +1,142 @@
+package actuator
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/pf-status-relay/pkg/config"
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+var _ = Describe("Selector", func() {
+	var sysfs string
+
+	vfs := []netlink.VfInfo{
+		{ID: 0, Mac: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10}, Vlan: 100},
+		{ID: 1, Mac: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x11}, Vlan: 200, Trust: 1},
+		{ID: 2, Mac: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x12}},
+		{ID: 3, Mac: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x13}},
+	}
+
+	// bind creates the sysfs entries of a VF bound to driver.
+	bind := func(id, driver string, net bool) {
+		virtfn := filepath.Join(sysfs, "class", "net", "test", "device", "virtfn"+id)
+		Expect(os.MkdirAll(virtfn, 0o755)).To(Succeed())
+		Expect(os.Symlink("../../../bus/pci/drivers/"+driver, filepath.Join(virtfn, "driver"))).To(Succeed())
+		if net {
+			Expect(os.Mkdir(filepath.Join(virtfn, "net"), 0o755)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		sysfs = GinkgoT().TempDir()
+		bind("0", "iavf", true)
+		bind("1", "vfio-pci", false)
+		bind("2", "iavf", true)
+	})
+
+	selectedIDs := func(conf config.VFSelection) []int {
+		s, err := NewSelector("test", conf)
+		Expect(err).NotTo(HaveOccurred())
+		s.sysfs = sysfs
+
+		var ids []int
+		for _, vf := range vfs {
+			if s.Selected(vf) {
+				ids = append(ids, vf.ID)
+			}
+		}
+		return ids
+	}
+
+	vlan := 100
+	trusted := true
+
+	DescribeTable("should select VFs",
+		func(conf config.VFSelection, expected []int) {
+			Expect(selectedIDs(conf)).To(Equal(expected))
+		},
+		Entry("without rules", config.VFSelection{}, []int{0, 1, 2, 3}),
+		Entry("by id range", config.VFSelection{Include: []config.VFRule{{IDs: "0-1, 3"}}}, []int{0, 1, 3}),
+		Entry("by mac", config.VFSelection{Exclude: []config.VFRule{{MAC: "02:00:00:00:00:12"}}}, []int{0, 1, 3}),
+		Entry("by vlan", config.VFSelection{Include: []config.VFRule{{VLAN: &vlan}}}, []int{0}),
+		Entry("by trust", config.VFSelection{Exclude: []config.VFRule{{Trust: &trusted}}}, []int{0, 2, 3}),
+		Entry("by driver", config.VFSelection{Exclude: []config.VFRule{{Driver: "vfio-pci"}}}, []int{0, 2, 3}),
+		Entry("by netdev driver", config.VFSelection{Include: []config.VFRule{{Driver: "netdev"}}}, []int{0, 2}),
+		Entry("by combined fields", config.VFSelection{Include: []config.VFRule{{IDs: "0-2", Driver: "iavf"}}}, []int{0, 2}),
+		Entry("with include and exclude", config.VFSelection{
+			Include: []config.VFRule{{IDs: "0-3"}},
+			Exclude: []config.VFRule{{IDs: "1"}, {MAC: "02:00:00:00:00:13"}},
+		}, []int{0, 2}),
+	)
+
+	DescribeTable("should reject invalid rules",
+		func(rule config.VFRule, message string) {
+			_, err := NewSelector("test", config.VFSelection{Include: []config.VFRule{rule}})
+			Expect(err).To(MatchError(message))
+		},
+		Entry("empty rule", config.VFRule{}, "invalid vf include rule: rule is empty"),
+		Entry("invalid id", config.VFRule{IDs: "a"}, `invalid vf include rule: invalid vf id "a"`),
+		Entry("invalid range", config.VFRule{IDs: "3-1"}, `invalid vf include rule: invalid vf id range "3-1"`),
+		Entry("invalid mac", config.VFRule{MAC: "invalid"}, "invalid vf include rule: address invalid: invalid MAC address"),
+	)
+
+	It("should only apply actuators to the selected VFs", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		mockNetlink := interfaces.NewMockNetlink(ctrl)
+
+		link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test", Vfs: []netlink.VfInfo{
+			{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO},
+			{ID: 1, LinkState: netlink.VF_LINK_STATE_AUTO},
+		}}}
+		mockNetlink.EXPECT().LinkSetVfState(gomock.Any(), 1, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1)
+
+		s, err := NewSelector("test", config.VFSelection{Exclude: []config.VFRule{{IDs: "0"}}})
+		Expect(err).NotTo(HaveOccurred())
+		a := Select(NewVfState("test", mockNetlink), s)
+		Expect(a.Name()).To(Equal("vfstate"))
+		Expect(a.Apply(link, false)).To(Succeed())
+		Expect(link.Attrs().Vfs).To(HaveLen(2))
+		Expect(Selected(a, link.Attrs().Vfs[0])).To(BeFalse())
+		Expect(Selected(a, link.Attrs().Vfs[1])).To(BeTrue())
+		Expect(Selected(NewVfState("test", mockNetlink), link.Attrs().Vfs[0])).To(BeTrue())
+	})
+	It("should restore the VFs it disabled after they are no longer selected", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		mockNetlink := interfaces.NewMockNetlink(ctrl)
+
+		mac := net.HardwareAddr{0x02, 0, 0, 0, 0, 0x10}
+		link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test", Vfs: []netlink.VfInfo{
+			{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO},
+		}}}
+
+		s, err := NewSelector("test", config.VFSelection{Exclude: []config.VFRule{{MAC: mac.String()}}})
+		Expect(err).NotTo(HaveOccurred())
+		v := NewVfState("test", mockNetlink)
+		a := Select(Chain{v}, s)
+
+		mockNetlink.EXPECT().LinkSetVfState(gomock.Any(), 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(1)
+		Expect(a.Apply(link, false)).To(Succeed())
+		Expect(v.Owned()).To(HaveKey(0))
+
+		// The MAC of the VF changes to an excluded one while it is disabled.
+		link.Vfs[0] = netlink.VfInfo{ID: 0, Mac: mac, LinkState: netlink.VF_LINK_STATE_DISABLE}
+		Expect(Selected(a, link.Vfs[0])).To(BeFalse())
+		Expect(a.Apply(link, false)).To(Succeed())
+		Expect(v.Owned()).To(HaveKey(0))
+
+		mockNetlink.EXPECT().LinkSetVfState(gomock.Any(), 0, uint32(netlink.VF_LINK_STATE_AUTO)).Return(nil).Times(1)
+		Expect(a.Apply(link, true)).To(Succeed())
+		Expect(v.Owned()).To(BeEmpty())
+
+		// The VF is not disabled anymore once restored.
+		link.Vfs[0].LinkState = netlink.VF_LINK_STATE_AUTO
+		Expect(a.Apply(link, false)).To(Succeed())
+	})
+})
@@ -0,0 +1,103 @@
+package actuator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	// defaultSysfsPath is where mlx5 exposes the link state of VFs, relative to the mount point of sysfs.
+	defaultSysfsPath = "class/net/{pf}/device/sriov/{vf}/link_state"
+	// defaultSysfsValues are the values written by mlx5 for the auto, enable and disable link states.
+	defaultSysfsValues = "Follow,Up,Down"
+)
+
+// SysfsLinkState reads and sets the link state of the VFs of a PF through sysfs, for drivers that do not support
+// it through netlink.
+type SysfsLinkState struct {
+	pf string
+	// root is the mount point of sysfs.
+	root string
+	// path is the path of the link state of a VF relative to root, where {pf} and {vf} are replaced with the name
+	// of the PF and the id of the VF.
+	path string
+	// values are the values of the auto, enable and disable link states, indexed by the netlink link state.
+	values [3]string
+}
+
+// newSysfsLinkState returns a SysfsLinkState configured by the path and values options.
+func newSysfsLinkState(pf string, options map[string]string) (*SysfsLinkState, error) {
+	s := &SysfsLinkState{pf: pf, root: "/sys", path: defaultSysfsPath}
+	if options["path"] != "" {
+		s.path = options["path"]
+	}
+
+	values := defaultSysfsValues
+	if options["values"] != "" {
+		values = options["values"]
+	}
+	list := splitList(values)
+	if len(list) != len(s.values) {
+		return nil, fmt.Errorf("invalid sysfs values %q, expected the values of auto, enable and disable", values)
+	}
+	copy(s.values[:], list)
+
+	return s, nil
+}
+
+// file returns the path of the link state of a VF.
+func (s *SysfsLinkState) file(id int) string {
+	path := strings.NewReplacer("{pf}", s.pf, "{vf}", strconv.Itoa(id)).Replace(s.path)
+	return filepath.Join(s.root, path)
+}
+
+// State returns the link state of a VF.
+func (s *SysfsLinkState) State(id int) (uint32, error) {
+	raw, err := os.ReadFile(s.file(id))
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(raw))
+	for state, v := range s.values {
+		if strings.EqualFold(value, v) {
+			return uint32(state), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown link state %q in %s", value, s.file(id))
+}
+
+// Set sets the link state of a VF. The file is not created if it does not exist.
+func (s *SysfsLinkState) Set(id int, state uint32) error {
+	if state > netlink.VF_LINK_STATE_DISABLE {
+		return fmt.Errorf("invalid link state %d", state)
+	}
+
+	f, err := os.OpenFile(s.file(id), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteString(s.values[state])
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// splitList splits a comma separated list, trimming spaces.
+func splitList(raw string) []string {
+	list := strings.Split(raw, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+
+	return list
+}
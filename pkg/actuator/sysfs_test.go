@@ -0,0 +1,130 @@
+package actuator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/sys/unix"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+var _ = Describe("Sysfs", func() {
+	var (
+		root string
+		link *netlink.Dummy
+	)
+
+	// linkState returns the content of the link state file of a VF.
+	linkState := func(id int) string {
+		raw, err := os.ReadFile(filepath.Join(root, "class", "net", "test", "device", "sriov", fmt.Sprint(id), "link_state"))
+		Expect(err).NotTo(HaveOccurred())
+		return string(raw)
+	}
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+		for id, state := range []string{"Follow\n", "Up\n", "Down\n"} {
+			dir := filepath.Join(root, "class", "net", "test", "device", "sriov", fmt.Sprint(id))
+			Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "link_state"), []byte(state), 0o644)).To(Succeed())
+		}
+
+		link = &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test", Vfs: []netlink.VfInfo{{ID: 0}, {ID: 1}, {ID: 2}}}}
+	})
+
+	newSysfs := func(options map[string]string) *SysfsLinkState {
+		s, err := newSysfsLinkState("test", options)
+		Expect(err).NotTo(HaveOccurred())
+		s.root = root
+		return s
+	}
+
+	It("should read and write the link state of VFs", func() {
+		s := newSysfs(nil)
+		Expect(s.State(0)).To(Equal(uint32(netlink.VF_LINK_STATE_AUTO)))
+		Expect(s.State(1)).To(Equal(uint32(netlink.VF_LINK_STATE_ENABLE)))
+		Expect(s.State(2)).To(Equal(uint32(netlink.VF_LINK_STATE_DISABLE)))
+
+		Expect(s.Set(0, netlink.VF_LINK_STATE_DISABLE)).To(Succeed())
+		Expect(linkState(0)).To(Equal("Down"))
+
+		By("not creating missing files")
+		Expect(s.Set(3, netlink.VF_LINK_STATE_DISABLE)).To(MatchError(os.ErrNotExist))
+	})
+
+	It("should support vendor specific paths and values", func() {
+		Expect(os.WriteFile(filepath.Join(root, "vf0"), []byte("auto\n"), 0o644)).To(Succeed())
+
+		s := newSysfs(map[string]string{"path": "vf{vf}", "values": "auto, enable, disable"})
+		Expect(s.State(0)).To(Equal(uint32(netlink.VF_LINK_STATE_AUTO)))
+
+		_, err := newSysfsLinkState("test", map[string]string{"values": "on,off"})
+		Expect(err).To(MatchError(`invalid sysfs values "on,off", expected the values of auto, enable and disable`))
+	})
+
+	It("should relay the health of the PF through sysfs", func() {
+		a, err := New("sysfs", "test", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		v := a.(*VfState)
+		v.sysfs.root = root
+		Expect(v.Name()).To(Equal("sysfs"))
+
+		Expect(v.Apply(link, false)).To(Succeed())
+		Expect(linkState(0)).To(Equal("Down"))
+		Expect(linkState(1)).To(Equal("Down"))
+		Expect(v.Owned()).To(Equal(map[int]uint32{0: netlink.VF_LINK_STATE_AUTO, 1: netlink.VF_LINK_STATE_ENABLE}))
+
+		Expect(v.Apply(link, true)).To(Succeed())
+		Expect(linkState(0)).To(Equal("Follow"))
+		Expect(linkState(1)).To(Equal("Up"))
+		Expect(linkState(2)).To(Equal("Down\n"))
+	})
+
+	It("should keep the VFs it disabled when their link state cannot be read", func() {
+		a, err := New("sysfs", "test", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		v := a.(*VfState)
+		v.sysfs.root = root
+
+		Expect(v.Apply(link, false)).To(Succeed())
+		Expect(v.Owned()).To(HaveKey(0))
+
+		file := filepath.Join(root, "class", "net", "test", "device", "sriov", "0", "link_state")
+		Expect(os.Rename(file, file+".bak")).To(Succeed())
+		Expect(v.Apply(link, true)).To(MatchError(os.ErrNotExist))
+		Expect(v.Owned()).To(Equal(map[int]uint32{0: netlink.VF_LINK_STATE_AUTO}))
+
+		Expect(os.Rename(file+".bak", file)).To(Succeed())
+		Expect(v.Apply(link, true)).To(Succeed())
+		Expect(linkState(0)).To(Equal("Follow"))
+		Expect(v.Owned()).To(BeEmpty())
+	})
+
+	It("should fall back to sysfs when netlink is not supported", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		mockNetlink := interfaces.NewMockNetlink(ctrl)
+		mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(unix.EOPNOTSUPP).Times(1)
+
+		a, err := New("vfstate", "test", mockNetlink, nil)
+		Expect(err).NotTo(HaveOccurred())
+		v := a.(*VfState)
+		v.sysfs.root = root
+
+		link.Vfs = []netlink.VfInfo{{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO}, {ID: 1, LinkState: netlink.VF_LINK_STATE_ENABLE}}
+		Expect(v.Apply(link, false)).To(Succeed())
+		Expect(linkState(0)).To(Equal("Down"))
+		Expect(linkState(1)).To(Equal("Down"))
+
+		By("not falling back when disabled")
+		a, err = New("vfstate", "test", mockNetlink, map[string]string{"fallback": "none"})
+		Expect(err).NotTo(HaveOccurred())
+		mockNetlink.EXPECT().LinkSetVfState(link, gomock.Any(), uint32(netlink.VF_LINK_STATE_DISABLE)).Return(unix.EOPNOTSUPP).Times(2)
+		Expect(a.Apply(link, false)).To(MatchError(unix.EOPNOTSUPP))
+	})
+})
@@ -0,0 +1,230 @@
+package actuator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync/atomic"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+func init() {
+	Register("vfstate", func(pf string, nl interfaces.Netlink, options map[string]string) (Actuator, error) {
+		v := NewVfState(pf, nl)
+
+		switch options["healthy"] {
+		case "", "original":
+		case "auto":
+			v.SetHealthyState(netlink.VF_LINK_STATE_AUTO)
+		case "enable":
+			v.SetHealthyState(netlink.VF_LINK_STATE_ENABLE)
+		default:
+			return nil, fmt.Errorf("invalid vfstate healthy state %q", options["healthy"])
+		}
+
+		switch options["fallback"] {
+		case "", "sysfs":
+			sysfs, err := newSysfsLinkState(pf, options)
+			if err != nil {
+				return nil, err
+			}
+			v.sysfs = sysfs
+		case "none":
+		default:
+			return nil, fmt.Errorf("invalid vfstate fallback %q", options["fallback"])
+		}
+
+		return v, nil
+	})
+
+	Register("sysfs", func(pf string, nl interfaces.Netlink, options map[string]string) (Actuator, error) {
+		sysfs, err := newSysfsLinkState(pf, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewSysfsVfState(pf, sysfs), nil
+	})
+}
+
+// VfState relays the health of a PF by setting the link state of its VFs. It records the VFs it disabled along
+// with their original state, so that only those VFs are restored when the PF recovers.
+type VfState struct {
+	ledger
+	nl   interfaces.Netlink
+	name string
+	// healthy is the state of the VFs when the PF is healthy. VFs are restored to their original state when nil.
+	healthy *uint32
+	// sysfs sets the link state of the VFs when the driver does not support it through netlink.
+	sysfs *SysfsLinkState
+	// useSysfs is set when sysfs is used instead of netlink.
+	useSysfs atomic.Bool
+}
+
+// NewVfState returns a VfState actuator for the given PF.
+func NewVfState(pf string, nl interfaces.Netlink) *VfState {
+	return &VfState{ledger: newLedger(pf, "link state", netlink.VF_LINK_STATE_DISABLE, stateName), nl: nl, name: "vfstate"}
+}
+
+// NewSysfsVfState returns a VfState actuator for the given PF that only uses sysfs.
+func NewSysfsVfState(pf string, sysfs *SysfsLinkState) *VfState {
+	v := &VfState{ledger: newLedger(pf, "link state", netlink.VF_LINK_STATE_DISABLE, stateName), name: "sysfs", sysfs: sysfs}
+	v.useSysfs.Store(true)
+
+	return v
+}
+
+// SetHealthyState sets the state of the VFs when the PF is healthy. VFs that are not disabled and whose state
+// does not match are then corrected.
+func (v *VfState) SetHealthyState(state uint32) {
+	v.healthy = &state
+}
+
+// target returns the state a VF is brought to when the PF is healthy.
+func (v *VfState) target(original uint32) uint32 {
+	if v.healthy != nil {
+		return *v.healthy
+	}
+
+	return original
+}
+
+// HealthyState returns the state of the VFs when the PF is healthy, or false when they are restored to their
+// original state.
+func (v *VfState) HealthyState() (uint32, bool) {
+	if v.healthy == nil {
+		return 0, false
+	}
+
+	return *v.healthy, true
+}
+
+// Idle returns true when the actuator does not own any VF and does not correct drifted VFs.
+func (v *VfState) Idle() bool {
+	return v.healthy == nil && v.ledger.Idle()
+}
+
+// Name returns the name of the actuator.
+func (v *VfState) Name() string {
+	return v.name
+}
+
+// Apply disables the VFs when the PF is not healthy, and restores the VFs it disabled to their original state, or
+// to the healthy state if set, otherwise. VFs disabled by someone else are left alone.
+func (v *VfState) Apply(link netlink.Link, healthy bool) error {
+	states, err := v.states(link)
+	errs := []error{err}
+	set, get := v.setter(link), v.getter(link)
+
+	if healthy && v.healthy != nil {
+		v.reconcile(link, states)
+		vfs := vfsOf(link)
+		var jobs []job
+		for _, id := range slices.Sorted(maps.Keys(states)) {
+			_, owned := v.owned[id]
+			if owned || states[id] == netlink.VF_LINK_STATE_DISABLE || states[id] == *v.healthy || !v.selects(vfs[id]) {
+				continue
+			}
+
+			log.Log.Info("vf link state drifted", "id", id, "state", stateName(states[id]), "interface", v.pf)
+			jobs = append(jobs, job{id: id, state: *v.healthy, done: func() {}})
+		}
+		errs = append(errs, v.run(context.Background(), link, jobs, true, set, get))
+	}
+
+	errs = append(errs, v.apply(link, states, healthy, v.target, set, get))
+
+	return errors.Join(errs...)
+}
+
+// Restore takes ownership of the VFs disabled by a previous instance that are still disabled.
+func (v *VfState) Restore(link netlink.Link, owned map[int]uint32) {
+	states, err := v.states(link)
+	if err != nil {
+		log.Log.Warn("failed to read vf link state", "interface", v.pf, "error", err)
+	}
+	v.restore(link, states, owned)
+}
+
+// Release restores every VF disabled by the actuator to its original state, or to the healthy state if set.
+func (v *VfState) Release(ctx context.Context, link netlink.Link) error {
+	states, err := v.states(link)
+	return errors.Join(err, v.release(ctx, link, states, v.target, v.setter(link), v.getter(link)))
+}
+
+// states returns the link state of the VFs. The VFs whose link state cannot be read are left out, and stay owned.
+func (v *VfState) states(link netlink.Link) (map[int]uint32, error) {
+	var errs []error
+	states := make(map[int]uint32, len(link.Attrs().Vfs))
+	for _, vf := range link.Attrs().Vfs {
+		state := vf.LinkState
+		if v.useSysfs.Load() {
+			var err error
+			state, err = v.sysfs.State(vf.ID)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("vf %d: %w", vf.ID, err))
+				continue
+			}
+		}
+
+		log.Log.Debug("vf info", "id", vf.ID, "state", state, "interface", v.pf)
+		states[vf.ID] = state
+	}
+
+	return states, errors.Join(errs...)
+}
+
+// setter returns a function that sets the link state of a VF, falling back to sysfs when the driver does not
+// support netlink.
+func (v *VfState) setter(link netlink.Link) setFunc {
+	return func(id int, state uint32) error {
+		if !v.useSysfs.Load() {
+			err := v.nl.LinkSetVfState(link, id, state)
+			if !errors.Is(err, unix.EOPNOTSUPP) || v.sysfs == nil {
+				return err
+			}
+			if v.useSysfs.CompareAndSwap(false, true) {
+				log.Log.Warn("vf link state is not supported through netlink, falling back to sysfs", "interface", v.pf)
+			}
+		}
+
+		return v.sysfs.Set(id, state)
+	}
+}
+
+// getter returns a function that reads the link state of the VFs, with a single request when netlink is used.
+func (v *VfState) getter(link netlink.Link) getFunc {
+	return func() (map[int]uint32, error) {
+		if v.useSysfs.Load() {
+			return v.states(link)
+		}
+
+		l, err := v.nl.LinkByIndex(link.Attrs().Index)
+		if err != nil {
+			return nil, err
+		}
+
+		return v.states(l)
+	}
+}
+
+// stateName returns the name of a VF link state.
+func stateName(state uint32) string {
+	switch state {
+	case netlink.VF_LINK_STATE_AUTO:
+		return "auto"
+	case netlink.VF_LINK_STATE_ENABLE:
+		return "enable"
+	case netlink.VF_LINK_STATE_DISABLE:
+		return "disable"
+	}
+
+	return fmt.Sprintf("unknown(%d)", state)
+}
@@ -0,0 +1,135 @@
+package bfd
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// freePort returns a UDP port that is free on the loopback interface.
+func freePort() uint16 {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer conn.Close()
+
+	return uint16(conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+var _ = Describe("BFD", func() {
+	Describe("Packet", func() {
+		It("should encode and decode a packet", func() {
+			p := Packet{
+				Diagnostic:            DiagControlDetectionTimeExpired,
+				State:                 Up,
+				Poll:                  true,
+				DetectMultiplier:      3,
+				MyDiscriminator:       1,
+				YourDiscriminator:     2,
+				DesiredMinTxInterval:  300 * time.Millisecond,
+				RequiredMinRxInterval: 50 * time.Millisecond,
+			}
+
+			var decoded Packet
+			Expect(decoded.Unmarshal(p.Marshal())).To(Succeed())
+			Expect(decoded).To(Equal(p))
+		})
+
+		DescribeTable("should reject invalid packets",
+			func(modify func([]byte) []byte, message string) {
+				p := Packet{State: Up, DetectMultiplier: 3, MyDiscriminator: 1, YourDiscriminator: 2}
+				var decoded Packet
+				Expect(decoded.Unmarshal(modify(p.Marshal()))).To(MatchError(message))
+			},
+			Entry("short packet", func(b []byte) []byte { return b[:20] }, "packet too short: 20 bytes"),
+			Entry("wrong version", func(b []byte) []byte { b[0] = 2 << 5; return b }, "unsupported version 2"),
+			Entry("wrong length", func(b []byte) []byte { b[3] = 30; return b }, "invalid length 30"),
+			Entry("authentication", func(b []byte) []byte { b[1] |= flagAuthenticated; return b }, "authentication is not supported"),
+			Entry("zero multiplier", func(b []byte) []byte { b[2] = 0; return b }, "detect multiplier is zero"),
+			Entry("zero discriminator", func(b []byte) []byte { copy(b[4:8], []byte{0, 0, 0, 0}); return b }, "my discriminator is zero"),
+			Entry("zero your discriminator when up", func(b []byte) []byte { copy(b[8:12], []byte{0, 0, 0, 0}); return b }, "your discriminator is zero in state Up"),
+		)
+	})
+
+	Describe("Session", func() {
+		var a, b *Session
+
+		BeforeEach(func() {
+			localhost := netip.MustParseAddr("127.0.0.1")
+			portA, portB := freePort(), freePort()
+
+			a = NewSession(Config{
+				Local:                 netip.AddrPortFrom(localhost, portA),
+				Peer:                  netip.AddrPortFrom(localhost, portB),
+				DesiredMinTxInterval:  20 * time.Millisecond,
+				RequiredMinRxInterval: 20 * time.Millisecond,
+				DetectMultiplier:      3,
+			})
+			b = NewSession(Config{
+				Local:                 netip.AddrPortFrom(localhost, portB),
+				Peer:                  netip.AddrPortFrom(localhost, portA),
+				DesiredMinTxInterval:  20 * time.Millisecond,
+				RequiredMinRxInterval: 20 * time.Millisecond,
+				DetectMultiplier:      3,
+			})
+		})
+
+		state := func(s *Session) func() State {
+			return func() State {
+				st, _ := s.State()
+				return st
+			}
+		}
+
+		It("should discard packets without discriminator unless the peer is down", func() {
+			a.handle(Packet{State: Up, MyDiscriminator: 7, DetectMultiplier: 3})
+			Expect(state(a)()).To(Equal(Down))
+
+			a.handle(Packet{State: Down, MyDiscriminator: 7, DetectMultiplier: 3})
+			Expect(state(a)()).To(Equal(Init))
+		})
+
+		It("should not send faster than once per second until it is up", func() {
+			Expect(a.txInterval()).To(BeNumerically(">=", 750*time.Millisecond))
+
+			a.handle(Packet{State: Down, MyDiscriminator: 7, DetectMultiplier: 3})
+			a.handle(Packet{State: Up, MyDiscriminator: 7, YourDiscriminator: a.localDiscriminator, DetectMultiplier: 3})
+			Expect(state(a)()).To(Equal(Up))
+			Expect(a.txInterval()).To(BeNumerically("<=", 20*time.Millisecond))
+		})
+
+		It("should come up with a peer and go down when the peer stops", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctxB, cancelB := context.WithCancel(ctx)
+
+			errs := make(chan error, 2)
+			go func() { errs <- a.Run(ctx) }()
+			go func() { errs <- b.Run(ctxB) }()
+
+			Expect(state(a)()).To(Equal(Down))
+			// Control packets are sent every second at most until the session is up.
+			Eventually(state(a), "5s", "10ms").Should(Equal(Up))
+			Eventually(state(b), "5s", "10ms").Should(Equal(Up))
+
+			By("stopping the peer")
+			cancelB()
+			Eventually(errs, "1s").Should(Receive(BeNil()))
+			Eventually(state(a), "1s", "10ms").Should(Equal(Down))
+			_, diagnostic := a.State()
+			Expect(diagnostic).To(Equal(DiagControlDetectionTimeExpired))
+
+			By("restarting the peer")
+			b = NewSession(b.conf)
+			go func() { errs <- b.Run(ctx) }()
+			Eventually(state(a), "5s", "10ms").Should(Equal(Up))
+
+			cancel()
+			Eventually(errs, "1s").Should(Receive(BeNil()))
+			Eventually(errs, "1s").Should(Receive(BeNil()))
+		})
+	})
+})
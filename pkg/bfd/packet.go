@@ -0,0 +1,160 @@
+package bfd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// State is the state of a BFD session.
+type State uint8
+
+const (
+	AdminDown State = iota
+	Down
+	Init
+	Up
+)
+
+func (s State) String() string {
+	switch s {
+	case AdminDown:
+		return "AdminDown"
+	case Down:
+		return "Down"
+	case Init:
+		return "Init"
+	case Up:
+		return "Up"
+	}
+
+	return fmt.Sprintf("State(%d)", uint8(s))
+}
+
+// Diagnostic explains the last change of state of a BFD session.
+type Diagnostic uint8
+
+const (
+	DiagNone Diagnostic = iota
+	DiagControlDetectionTimeExpired
+	DiagEchoFunctionFailed
+	DiagNeighborSignaledSessionDown
+	DiagForwardingPlaneReset
+	DiagPathDown
+	DiagConcatenatedPathDown
+	DiagAdministrativelyDown
+	DiagReverseConcatenatedPathDown
+)
+
+func (d Diagnostic) String() string {
+	switch d {
+	case DiagNone:
+		return "no diagnostic"
+	case DiagControlDetectionTimeExpired:
+		return "control detection time expired"
+	case DiagEchoFunctionFailed:
+		return "echo function failed"
+	case DiagNeighborSignaledSessionDown:
+		return "neighbor signaled session down"
+	case DiagForwardingPlaneReset:
+		return "forwarding plane reset"
+	case DiagPathDown:
+		return "path down"
+	case DiagConcatenatedPathDown:
+		return "concatenated path down"
+	case DiagAdministrativelyDown:
+		return "administratively down"
+	case DiagReverseConcatenatedPathDown:
+		return "reverse concatenated path down"
+	}
+
+	return fmt.Sprintf("Diagnostic(%d)", uint8(d))
+}
+
+const (
+	version   = 1
+	packetLen = 24
+
+	flagPoll          = 1 << 5
+	flagFinal         = 1 << 4
+	flagAuthenticated = 1 << 2
+	flagMultipoint    = 1
+)
+
+// Packet is a BFD control packet as described in RFC 5880 section 4.1. Authentication is not supported.
+type Packet struct {
+	Diagnostic                Diagnostic
+	State                     State
+	Poll                      bool
+	Final                     bool
+	DetectMultiplier          uint8
+	MyDiscriminator           uint32
+	YourDiscriminator         uint32
+	DesiredMinTxInterval      time.Duration
+	RequiredMinRxInterval     time.Duration
+	RequiredMinEchoRxInterval time.Duration
+}
+
+// Marshal encodes the packet.
+func (p *Packet) Marshal() []byte {
+	b := make([]byte, packetLen)
+	b[0] = version<<5 | uint8(p.Diagnostic)&0x1f
+	b[1] = uint8(p.State) << 6
+	if p.Poll {
+		b[1] |= flagPoll
+	}
+	if p.Final {
+		b[1] |= flagFinal
+	}
+	b[2] = p.DetectMultiplier
+	b[3] = packetLen
+	binary.BigEndian.PutUint32(b[4:], p.MyDiscriminator)
+	binary.BigEndian.PutUint32(b[8:], p.YourDiscriminator)
+	binary.BigEndian.PutUint32(b[12:], uint32(p.DesiredMinTxInterval.Microseconds()))
+	binary.BigEndian.PutUint32(b[16:], uint32(p.RequiredMinRxInterval.Microseconds()))
+	binary.BigEndian.PutUint32(b[20:], uint32(p.RequiredMinEchoRxInterval.Microseconds()))
+
+	return b
+}
+
+// Unmarshal decodes and validates a packet following RFC 5880 section 6.8.6.
+func (p *Packet) Unmarshal(b []byte) error {
+	if len(b) < packetLen {
+		return fmt.Errorf("packet too short: %d bytes", len(b))
+	}
+	if v := b[0] >> 5; v != version {
+		return fmt.Errorf("unsupported version %d", v)
+	}
+	if l := int(b[3]); l < packetLen || l > len(b) {
+		return fmt.Errorf("invalid length %d", l)
+	}
+	if b[1]&flagAuthenticated != 0 {
+		return fmt.Errorf("authentication is not supported")
+	}
+	if b[1]&flagMultipoint != 0 {
+		return fmt.Errorf("multipoint bit is set")
+	}
+	if b[2] == 0 {
+		return fmt.Errorf("detect multiplier is zero")
+	}
+
+	p.Diagnostic = Diagnostic(b[0] & 0x1f)
+	p.State = State(b[1] >> 6)
+	p.Poll = b[1]&flagPoll != 0
+	p.Final = b[1]&flagFinal != 0
+	p.DetectMultiplier = b[2]
+	p.MyDiscriminator = binary.BigEndian.Uint32(b[4:])
+	p.YourDiscriminator = binary.BigEndian.Uint32(b[8:])
+	p.DesiredMinTxInterval = time.Duration(binary.BigEndian.Uint32(b[12:])) * time.Microsecond
+	p.RequiredMinRxInterval = time.Duration(binary.BigEndian.Uint32(b[16:])) * time.Microsecond
+	p.RequiredMinEchoRxInterval = time.Duration(binary.BigEndian.Uint32(b[20:])) * time.Microsecond
+
+	if p.MyDiscriminator == 0 {
+		return fmt.Errorf("my discriminator is zero")
+	}
+	if p.YourDiscriminator == 0 && p.State != Down && p.State != AdminDown {
+		return fmt.Errorf("your discriminator is zero in state %s", p.State)
+	}
+
+	return nil
+}
@@ -0,0 +1,337 @@
+package bfd
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+const (
+	// Port is the destination port of single-hop BFD control packets (RFC 5881).
+	Port = 3784
+	// ttl is the TTL of sent packets and the only TTL accepted on received packets (RFC 5881 section 5).
+	ttl = 255
+
+	minSourcePort = 49152
+	maxSourcePort = 65535
+
+	// slowTxInterval is the minimum interval between control packets while the session is not up (RFC 5880
+	// section 6.8.3).
+	slowTxInterval = time.Second
+)
+
+// Config contains the parameters of a BFD session.
+type Config struct {
+	// Local is the address and port where control packets are received.
+	Local netip.AddrPort
+	// Peer is the address and port where control packets are sent.
+	Peer netip.AddrPort
+	// DesiredMinTxInterval is the minimum interval at which the session wants to send control packets.
+	DesiredMinTxInterval time.Duration
+	// RequiredMinRxInterval is the minimum interval at which the session can receive control packets.
+	RequiredMinRxInterval time.Duration
+	// DetectMultiplier is the number of missed packets after which the peer declares the session down.
+	DetectMultiplier uint8
+}
+
+// Session is a single-hop BFD session in asynchronous mode. Demand mode, echo and authentication are not supported.
+type Session struct {
+	conf Config
+
+	mu                 sync.Mutex
+	state              State
+	diagnostic         Diagnostic
+	localDiscriminator uint32
+	remote             Packet
+}
+
+// NewSession returns a session in Down state.
+func NewSession(conf Config) *Session {
+	return &Session{
+		conf:               conf,
+		state:              Down,
+		localDiscriminator: rand.Uint32() | 1,
+	}
+}
+
+// State returns the state of the session and the diagnostic of its last change.
+func (s *Session) State() (State, Diagnostic) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state, s.diagnostic
+}
+
+// Run runs the session until ctx is cancelled.
+func (s *Session) Run(ctx context.Context) error {
+	rx, err := listen(ctx, s.conf.Local)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.conf.Local, err)
+	}
+	defer rx.Close()
+
+	tx, err := dialPeer(ctx, s.conf.Local.Addr(), s.conf.Peer)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", s.conf.Peer, err)
+	}
+	defer tx.Close()
+
+	packets := make(chan Packet)
+	errs := make(chan error, 1)
+	go s.receive(ctx, rx, packets, errs)
+
+	txTimer := time.NewTimer(0)
+	defer txTimer.Stop()
+	detectTimer := time.NewTimer(time.Hour)
+	detectTimer.Stop()
+	defer detectTimer.Stop()
+
+	for {
+		select {
+		case p := <-packets:
+			before, _ := s.State()
+			final := s.handle(p)
+			detectTimer.Reset(s.detectionTime())
+			if final {
+				s.send(tx, true)
+			}
+			// The transmit interval changes with the state, which the peer learns from the next packet.
+			if state, _ := s.State(); state != before {
+				txTimer.Reset(0)
+			}
+		case <-detectTimer.C:
+			s.expire()
+		case <-txTimer.C:
+			s.send(tx, false)
+			txTimer.Reset(s.txInterval())
+		case err := <-errs:
+			return fmt.Errorf("failed to read bfd packet: %w", err)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// receive reads control packets from conn until ctx is cancelled. A read error is sent to errs, so that the
+// session is restarted.
+func (s *Session) receive(ctx context.Context, conn *net.UDPConn, packets chan<- Packet, errs chan<- error) {
+	buf := make([]byte, 128)
+	oob := make([]byte, 128)
+	for {
+		n, oobn, _, from, err := conn.ReadMsgUDPAddrPort(buf, oob)
+		if err != nil {
+			if ctx.Err() == nil {
+				errs <- err
+			}
+			return
+		}
+
+		if from.Addr().Unmap() != s.conf.Peer.Addr() {
+			continue
+		}
+		if t, ok := receivedTTL(oob[:oobn]); !ok || t != ttl {
+			log.Log.Debug("discarding bfd packet with invalid ttl", "peer", from)
+			continue
+		}
+
+		var p Packet
+		if err := p.Unmarshal(buf[:n]); err != nil {
+			log.Log.Debug("discarding invalid bfd packet", "peer", from, "error", err)
+			continue
+		}
+
+		select {
+		case packets <- p:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// handle runs the reception of a control packet as described in RFC 5880 section 6.8.6. It returns true when the
+// packet has the poll bit and must be answered with the final bit.
+func (s *Session) handle(p Packet) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.YourDiscriminator != 0 && p.YourDiscriminator != s.localDiscriminator {
+		return false
+	}
+	if p.YourDiscriminator == 0 && p.State != Down && p.State != AdminDown {
+		return false
+	}
+
+	s.remote = p
+
+	if s.state == AdminDown {
+		return false
+	}
+
+	if p.State == AdminDown {
+		if s.state != Down {
+			s.transition(Down, DiagNeighborSignaledSessionDown)
+		}
+		return p.Poll
+	}
+
+	switch s.state {
+	case Down:
+		switch p.State {
+		case Down:
+			s.transition(Init, DiagNone)
+		case Init:
+			s.transition(Up, DiagNone)
+		}
+	case Init:
+		if p.State == Init || p.State == Up {
+			s.transition(Up, DiagNone)
+		}
+	case Up:
+		if p.State == Down {
+			s.transition(Down, DiagNeighborSignaledSessionDown)
+		}
+	}
+
+	return p.Poll
+}
+
+// expire brings the session down when no packet was received within the detection time.
+func (s *Session) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == Init || s.state == Up {
+		s.transition(Down, DiagControlDetectionTimeExpired)
+	}
+	s.remote.MyDiscriminator = 0
+}
+
+func (s *Session) transition(state State, diagnostic Diagnostic) {
+	log.Log.Info("bfd session state changed", "peer", s.conf.Peer, "from", s.state.String(), "to", state.String(), "diagnostic", diagnostic.String())
+	s.state = state
+	s.diagnostic = diagnostic
+}
+
+// send sends a control packet to the peer.
+func (s *Session) send(conn *net.UDPConn, final bool) {
+	s.mu.Lock()
+	p := Packet{
+		Diagnostic:            s.diagnostic,
+		State:                 s.state,
+		Final:                 final,
+		DetectMultiplier:      s.conf.DetectMultiplier,
+		MyDiscriminator:       s.localDiscriminator,
+		YourDiscriminator:     s.remote.MyDiscriminator,
+		DesiredMinTxInterval:  s.desiredMinTxInterval(),
+		RequiredMinRxInterval: s.conf.RequiredMinRxInterval,
+	}
+	s.mu.Unlock()
+
+	_, err := conn.Write(p.Marshal())
+	if err != nil {
+		log.Log.Debug("failed to send bfd packet", "peer", s.conf.Peer, "error", err)
+	}
+}
+
+// txInterval returns the interval until the next control packet, reduced by a random jitter of up to 25%.
+func (s *Session) txInterval() time.Duration {
+	s.mu.Lock()
+	interval := max(s.desiredMinTxInterval(), s.remote.RequiredMinRxInterval)
+	s.mu.Unlock()
+
+	return interval - time.Duration(rand.Int64N(int64(interval/4)+1))
+}
+
+// desiredMinTxInterval returns the minimum interval at which the session wants to send control packets, which is
+// at least slowTxInterval while it is not up. It must be called with the lock held.
+func (s *Session) desiredMinTxInterval() time.Duration {
+	if s.state != Up {
+		return max(s.conf.DesiredMinTxInterval, slowTxInterval)
+	}
+
+	return s.conf.DesiredMinTxInterval
+}
+
+// detectionTime returns the time after which the session is declared down if no packet is received.
+func (s *Session) detectionTime() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return time.Duration(s.remote.DetectMultiplier) * max(s.conf.RequiredMinRxInterval, s.remote.DesiredMinTxInterval)
+}
+
+// listen opens the socket where control packets are received, asking the kernel for the TTL of every packet.
+func listen(ctx context.Context, local netip.AddrPort) (*net.UDPConn, error) {
+	lc := net.ListenConfig{Control: func(_, _ string, c syscall.RawConn) error {
+		var serr error
+		err := c.Control(func(fd uintptr) {
+			serr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_RECVTTL, 1)
+		})
+		return errors.Join(err, serr)
+	}}
+
+	conn, err := lc.ListenPacket(ctx, "udp4", local.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return conn.(*net.UDPConn), nil
+}
+
+// dialPeer opens the socket where control packets are sent from a source port in the range required by RFC 5881.
+func dialPeer(ctx context.Context, local netip.Addr, peer netip.AddrPort) (*net.UDPConn, error) {
+	var err error
+	start := rand.IntN(maxSourcePort - minSourcePort + 1)
+	for i := range maxSourcePort - minSourcePort + 1 {
+		port := minSourcePort + (start+i)%(maxSourcePort-minSourcePort+1)
+		d := net.Dialer{
+			LocalAddr: net.UDPAddrFromAddrPort(netip.AddrPortFrom(local, uint16(port))),
+			Control: func(_, _ string, c syscall.RawConn) error {
+				var serr error
+				err := c.Control(func(fd uintptr) {
+					serr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TTL, ttl)
+				})
+				return errors.Join(err, serr)
+			},
+		}
+
+		var conn net.Conn
+		conn, err = d.DialContext(ctx, "udp4", peer.String())
+		if err == nil {
+			return conn.(*net.UDPConn), nil
+		}
+		if !errors.Is(err, unix.EADDRINUSE) {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+// receivedTTL returns the TTL from the control messages of a received packet, which the kernel writes as a native
+// endian int.
+func receivedTTL(oob []byte) (int, bool) {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0, false
+	}
+
+	for _, m := range msgs {
+		if m.Header.Level == unix.IPPROTO_IP && m.Header.Type == unix.IP_TTL && len(m.Data) >= 4 {
+			return int(int32(binary.NativeEndian.Uint32(m.Data))), true
+		}
+	}
+
+	return 0, false
+}
@@ -1,23 +1,218 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
+	"go.yaml.in/yaml/v3"
+
 	"github.com/openshift/pf-status-relay/pkg/log"
 )
 
 const (
-	pfStatusRelayPollingInterval = "PF_STATUS_RELAY_POLLING_INTERVAL"
-	pfStatusRelayInterfaces      = "PF_STATUS_RELAY_INTERFACES"
+	pfStatusRelayConfigFile           = "PF_STATUS_RELAY_CONFIG_FILE"
+	pfStatusRelayPollingInterval      = "PF_STATUS_RELAY_POLLING_INTERVAL"
+	pfStatusRelayMinPollingInterval   = "PF_STATUS_RELAY_MIN_POLLING_INTERVAL"
+	pfStatusRelayInterfaces           = "PF_STATUS_RELAY_INTERFACES"
+	pfStatusRelayDetectors            = "PF_STATUS_RELAY_DETECTORS"
+	pfStatusRelayHealth               = "PF_STATUS_RELAY_HEALTH"
+	pfStatusRelayHoldDown             = "PF_STATUS_RELAY_HOLD_DOWN"
+	pfStatusRelayStateFile            = "PF_STATUS_RELAY_STATE_FILE"
+	pfStatusRelayShutdownPolicy       = "PF_STATUS_RELAY_SHUTDOWN_POLICY"
+	pfStatusRelayShutdownTimeout      = "PF_STATUS_RELAY_SHUTDOWN_TIMEOUT"
+	pfStatusRelayActuationAttempts    = "PF_STATUS_RELAY_ACTUATION_ATTEMPTS"
+	pfStatusRelayActuationBackoff     = "PF_STATUS_RELAY_ACTUATION_BACKOFF"
+	pfStatusRelayActuationMaxBackoff  = "PF_STATUS_RELAY_ACTUATION_MAX_BACKOFF"
+	pfStatusRelayActuationVerify      = "PF_STATUS_RELAY_ACTUATION_VERIFY"
+	pfStatusRelayActuationConcurrency = "PF_STATUS_RELAY_ACTUATION_CONCURRENCY"
+	pfStatusRelayActuationRate        = "PF_STATUS_RELAY_ACTUATION_RATE"
+	pfStatusRelayLinkDump             = "PF_STATUS_RELAY_LINK_DUMP"
+	pfStatusRelayLogLevel             = "PF_STATUS_RELAY_LOG_LEVEL"
+	pfStatusRelayHTTPAddress          = "PF_STATUS_RELAY_HTTP_ADDRESS"
+	pfStatusRelayStallTimeout         = "PF_STATUS_RELAY_STALL_TIMEOUT"
+	pfStatusRelayStatusSocket         = "PF_STATUS_RELAY_STATUS_SOCKET"
+	pfStatusRelayLogFormat            = "PF_STATUS_RELAY_LOG_FORMAT"
+	pfStatusRelayLogOutput            = "PF_STATUS_RELAY_LOG_OUTPUT"
+	pfStatusRelayLogMaxSize           = "PF_STATUS_RELAY_LOG_MAX_SIZE"
+	pfStatusRelayLogMaxBackups        = "PF_STATUS_RELAY_LOG_MAX_BACKUPS"
 )
 
 // Config contains the configuration of the application.
 type Config struct {
 	Interfaces      []string `yaml:"interfaces"`
 	PollingInterval int      `yaml:"pollingInterval"`
+	// MinPollingInterval is the polling interval in milliseconds right after a PF changed, which slows down back to
+	// PollingInterval once PFs are stable. Polling is not adaptive when it is equal to PollingInterval.
+	MinPollingInterval int `yaml:"minPollingInterval"`
+	// HoldDown is the time in milliseconds a PF must stay healthy before its VFs are brought back.
+	HoldDown int `yaml:"holdDown"`
+	// StateFile is the path of the journal where the VFs owned by the relay are persisted. It is disabled when empty.
+	// Its directory must be a hostPath volume for the journal to survive pod restarts.
+	StateFile string `yaml:"stateFile"`
+	// ShutdownPolicy determines what happens to the VFs disabled by the relay when it stops.
+	ShutdownPolicy ShutdownPolicy `yaml:"shutdownPolicy"`
+	// ShutdownTimeout is the time in milliseconds the shutdown policy has to complete.
+	ShutdownTimeout int `yaml:"shutdownTimeout"`
+	// ActuationAttempts is the maximum number of times the state of a VF is set before actuation fails.
+	ActuationAttempts int `yaml:"actuationAttempts"`
+	// ActuationBackoff is the time in milliseconds before the first retry. It doubles after every retry, up to
+	// ActuationMaxBackoff.
+	ActuationBackoff    int `yaml:"actuationBackoff"`
+	ActuationMaxBackoff int `yaml:"actuationMaxBackoff"`
+	// ActuationVerify reads back the state of the VFs after setting them, with a single request per PF.
+	ActuationVerify bool `yaml:"actuationVerify"`
+	// ActuationConcurrency is the number of VF states of a PF that are set in parallel.
+	ActuationConcurrency int `yaml:"actuationConcurrency"`
+	// ActuationRate is the maximum number of VF states of a PF that are set per second. It is not limited when 0.
+	ActuationRate float64 `yaml:"actuationRate"`
+	// LinkDump fetches all links in a single dump per polling interval instead of one request per PF and bond.
+	LinkDump bool `yaml:"linkDump"`
+	// HTTPAddress is the address of the HTTP server exposing the metrics. It is disabled when empty.
+	HTTPAddress string `yaml:"httpAddress"`
+	// StallTimeout is the time in milliseconds after which the monitor loop and the subscription to link changes
+	// are reported as stalled by the liveness endpoint when they did not make progress. It defaults to
+	// stallPolls polling intervals.
+	StallTimeout int `yaml:"stallTimeout"`
+	// StatusSocket is the path of the unix socket serving the status of the PFs. It is disabled when empty.
+	StatusSocket string `yaml:"statusSocket"`
+	// Log is the configuration of the logs.
+	Log log.Config `yaml:"log"`
+
+	// Defaults is the configuration used by PFs that are not listed in PFs.
+	Defaults PFConfig `yaml:"defaults"`
+	// PFs contains the configuration of each PF by interface name.
+	PFs map[string]PFConfig `yaml:"pfs"`
+}
+
+// ShutdownPolicy determines what happens to the VFs disabled by the relay when it stops.
+type ShutdownPolicy string
+
+const (
+	// ShutdownLeave leaves the VFs disabled. They are restored by the next instance through the state journal.
+	ShutdownLeave ShutdownPolicy = "leave"
+	// ShutdownRestore restores the VFs to their original state.
+	ShutdownRestore ShutdownPolicy = "restore"
+	// ShutdownRestoreIfUp restores the VFs to their original state only if their PF is healthy.
+	ShutdownRestoreIfUp ShutdownPolicy = "restoreIfUp"
+)
+
+// PFConfig contains the configuration of a PF.
+type PFConfig struct {
+	// Detectors are chained to determine the health of the PF. The PF is healthy when all of them report so,
+	// unless Health is set.
+	Detectors []PluginConfig `yaml:"detectors"`
+	// Health is an expression over the detector ids that determines the health of the PF (i.e. "lacp && carrier").
+	Health string `yaml:"health"`
+	// Actuators relay the health of the PF.
+	Actuators []PluginConfig `yaml:"actuators"`
+	// VFs selects the VFs the actuators act on. All VFs are selected by default.
+	VFs VFSelection `yaml:"vfs"`
+	// Priority orders the VFs the actuators act on.
+	Priority Priority `yaml:"priority"`
+}
+
+// Priority assigns priorities to VFs. VFs with a higher priority are disabled first when the PF fails, and
+// restored last when it recovers. The priority of a VF is the highest one it is assigned, and VFs that are not
+// assigned any are ordered by their usage by pods.
+type Priority struct {
+	// IDs maps comma separated lists of VF indexes and ranges (i.e. "0-3,7") to their priority.
+	IDs map[string]int `yaml:"ids"`
+	// MACs maps the MAC addresses of VFs to their priority.
+	MACs map[string]int `yaml:"macs"`
+	// Kubelet assigns a priority to the VFs allocated to pods by kubelet.
+	Kubelet *KubeletPriority `yaml:"kubelet"`
+}
+
+// KubeletPriority assigns a priority to the VFs allocated to pods, as recorded in the checkpoint of the device
+// manager of kubelet.
+type KubeletPriority struct {
+	// Checkpoint is the path of the checkpoint. It defaults to DefaultKubeletCheckpoint.
+	Checkpoint string `yaml:"checkpoint"`
+	// Priority is the priority of the VFs allocated to pods.
+	Priority int `yaml:"priority"`
+	// Resources maps resource names (i.e. "openshift.io/prod") to the priority of the VFs allocated from them,
+	// overriding Priority.
+	Resources map[string]int `yaml:"resources"`
+}
+
+// DefaultKubeletCheckpoint is the path of the checkpoint of the device manager of kubelet.
+const DefaultKubeletCheckpoint = "/var/lib/kubelet/device-plugins/kubelet_internal_checkpoint"
+
+// stallPolls is the number of polling intervals without progress after which the relay is reported as stalled by
+// default.
+const stallPolls = 30
+
+// VFSelection selects VFs by rules. A VF is selected when it matches any include rule, or there are none, and
+// it does not match any exclude rule.
+type VFSelection struct {
+	Include []VFRule `yaml:"include"`
+	Exclude []VFRule `yaml:"exclude"`
+}
+
+// VFRule matches the VFs for which all of its set fields match.
+type VFRule struct {
+	// IDs is a comma separated list of VF indexes and ranges (i.e. "0-3,7").
+	IDs  string `yaml:"ids"`
+	MAC  string `yaml:"mac"`
+	VLAN *int   `yaml:"vlan"`
+	// Trust matches the trust flag of the VF.
+	Trust *bool `yaml:"trust"`
+	// Driver matches the driver bound to the VF (i.e. "vfio-pci"). The special value "netdev" matches VFs that
+	// are bound to a driver exposing a network interface.
+	Driver string `yaml:"driver"`
+}
+
+// PluginConfig selects a registered detector or actuator.
+type PluginConfig struct {
+	Name string `yaml:"name"`
+	// ID identifies the detector in health expressions. It defaults to Name.
+	ID      string            `yaml:"id"`
+	Options map[string]string `yaml:"options"`
+}
+
+// Identifier returns the id of the plugin, which defaults to its name.
+func (p PluginConfig) Identifier() string {
+	if p.ID != "" {
+		return p.ID
+	}
+
+	return p.Name
+}
+
+// PF returns the configuration of the PF with the given name.
+func (c Config) PF(name string) PFConfig {
+	pf := c.PFs[name]
+	if len(pf.Detectors) == 0 {
+		pf.Detectors = c.Defaults.Detectors
+	}
+	if len(pf.Detectors) == 0 {
+		pf.Detectors = []PluginConfig{{Name: "lacp"}}
+	}
+
+	if pf.Health == "" {
+		pf.Health = c.Defaults.Health
+	}
+
+	if len(pf.Actuators) == 0 {
+		pf.Actuators = c.Defaults.Actuators
+	}
+	if len(pf.Actuators) == 0 {
+		pf.Actuators = []PluginConfig{{Name: "vfstate"}}
+	}
+
+	if len(pf.VFs.Include) == 0 && len(pf.VFs.Exclude) == 0 {
+		pf.VFs = c.Defaults.VFs
+	}
+
+	if pf.Priority.IDs == nil && pf.Priority.MACs == nil && pf.Priority.Kubelet == nil {
+		pf.Priority = c.Defaults.Priority
+	}
+
+	return pf
 }
 
 // ReadConfig read yaml config file.
@@ -25,6 +220,30 @@ func ReadConfig() (Config, error) {
 	c := Config{}
 
 	c.PollingInterval = 1000
+	c.StateFile = "/run/pf-status-relay/state.json"
+	c.ShutdownPolicy = ShutdownLeave
+	c.ShutdownTimeout = 5000
+	c.ActuationAttempts = 3
+	c.ActuationBackoff = 100
+	c.ActuationMaxBackoff = 1000
+	c.ActuationVerify = true
+	c.ActuationConcurrency = 1
+	c.Log = log.Config{Level: "info", Format: "json", Output: "stdout", MaxBackups: 3}
+	path, ok := os.LookupEnv(pfStatusRelayConfigFile)
+	if ok && path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return c, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		d := yaml.NewDecoder(bytes.NewReader(raw))
+		d.KnownFields(true)
+		err = d.Decode(&c)
+		if err != nil {
+			return c, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
 	raw, ok := os.LookupEnv(pfStatusRelayPollingInterval)
 	if ok && raw != "" {
 		pollingInterval, err := strconv.Atoi(raw)
@@ -32,23 +251,278 @@ func ReadConfig() (Config, error) {
 			return c, fmt.Errorf("failed to convert polling interval to int: %w", err)
 		}
 
-		if pollingInterval < 100 {
-			return c, fmt.Errorf("polling interval must be greater than 100 - current value: %d", pollingInterval)
+		c.PollingInterval = pollingInterval
+	}
+
+	if c.PollingInterval < 100 {
+		return c, fmt.Errorf("polling interval must be greater than 100 - current value: %d", c.PollingInterval)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayMinPollingInterval)
+	if ok && raw != "" {
+		minPollingInterval, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert min polling interval to int: %w", err)
+		}
+
+		c.MinPollingInterval = minPollingInterval
+	}
+
+	if c.MinPollingInterval == 0 {
+		c.MinPollingInterval = c.PollingInterval
+	}
+
+	if c.MinPollingInterval < 100 || c.MinPollingInterval > c.PollingInterval {
+		return c, fmt.Errorf("min polling interval must be between 100 and the polling interval - current value: %d", c.MinPollingInterval)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayHoldDown)
+	if ok && raw != "" {
+		holdDown, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert hold down to int: %w", err)
 		}
 
-		c.PollingInterval = pollingInterval
+		c.HoldDown = holdDown
+	}
+
+	if c.HoldDown < 0 {
+		return c, fmt.Errorf("hold down must not be negative - current value: %d", c.HoldDown)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayStateFile)
+	if ok {
+		c.StateFile = raw
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayShutdownPolicy)
+	if ok && raw != "" {
+		c.ShutdownPolicy = ShutdownPolicy(raw)
+	}
+
+	switch c.ShutdownPolicy {
+	case ShutdownLeave, ShutdownRestore, ShutdownRestoreIfUp:
+	default:
+		return c, fmt.Errorf("shutdown policy must be one of %s, %s or %s - current value: %s", ShutdownLeave, ShutdownRestore, ShutdownRestoreIfUp, c.ShutdownPolicy)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayShutdownTimeout)
+	if ok && raw != "" {
+		shutdownTimeout, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert shutdown timeout to int: %w", err)
+		}
+
+		c.ShutdownTimeout = shutdownTimeout
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		return c, fmt.Errorf("shutdown timeout must be positive - current value: %d", c.ShutdownTimeout)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayActuationAttempts)
+	if ok && raw != "" {
+		attempts, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert actuation attempts to int: %w", err)
+		}
+
+		c.ActuationAttempts = attempts
+	}
+
+	if c.ActuationAttempts < 1 {
+		return c, fmt.Errorf("actuation attempts must be at least 1 - current value: %d", c.ActuationAttempts)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayActuationBackoff)
+	if ok && raw != "" {
+		backoff, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert actuation backoff to int: %w", err)
+		}
+
+		c.ActuationBackoff = backoff
+	}
+
+	if c.ActuationBackoff < 0 {
+		return c, fmt.Errorf("actuation backoff must not be negative - current value: %d", c.ActuationBackoff)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayActuationMaxBackoff)
+	if ok && raw != "" {
+		maxBackoff, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert actuation max backoff to int: %w", err)
+		}
+
+		c.ActuationMaxBackoff = maxBackoff
+	}
+
+	if c.ActuationMaxBackoff < c.ActuationBackoff {
+		return c, fmt.Errorf("actuation max backoff must not be lower than the actuation backoff - current value: %d", c.ActuationMaxBackoff)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayActuationVerify)
+	if ok && raw != "" {
+		verify, err := strconv.ParseBool(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert actuation verify to bool: %w", err)
+		}
+
+		c.ActuationVerify = verify
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayActuationConcurrency)
+	if ok && raw != "" {
+		concurrency, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert actuation concurrency to int: %w", err)
+		}
+
+		c.ActuationConcurrency = concurrency
+	}
+
+	if c.ActuationConcurrency < 1 {
+		return c, fmt.Errorf("actuation concurrency must be at least 1 - current value: %d", c.ActuationConcurrency)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayActuationRate)
+	if ok && raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert actuation rate to float: %w", err)
+		}
+
+		c.ActuationRate = rate
+	}
+
+	if c.ActuationRate < 0 {
+		return c, fmt.Errorf("actuation rate must not be negative - current value: %g", c.ActuationRate)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayLinkDump)
+	if ok && raw != "" {
+		linkDump, err := strconv.ParseBool(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert link dump to bool: %w", err)
+		}
+
+		c.LinkDump = linkDump
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayHTTPAddress)
+	if ok {
+		c.HTTPAddress = raw
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayStallTimeout)
+	if ok && raw != "" {
+		stallTimeout, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert stall timeout to int: %w", err)
+		}
+
+		c.StallTimeout = stallTimeout
+	}
+
+	if c.StallTimeout == 0 {
+		c.StallTimeout = stallPolls * c.PollingInterval
+	}
+
+	if c.StallTimeout <= c.PollingInterval {
+		return c, fmt.Errorf("stall timeout must be greater than the polling interval - current value: %d", c.StallTimeout)
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayStatusSocket)
+	if ok {
+		c.StatusSocket = raw
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayLogLevel)
+	if ok && raw != "" {
+		// The level is followed by the level of components, i.e. "info,monitor=debug".
+		c.Log.Components = make(map[string]string)
+		for _, l := range strings.Split(raw, ",") {
+			component, level, found := strings.Cut(strings.TrimSpace(l), "=")
+			if !found {
+				c.Log.Level = component
+				continue
+			}
+			c.Log.Components[component] = level
+		}
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayLogFormat)
+	if ok && raw != "" {
+		c.Log.Format = raw
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayLogOutput)
+	if ok && raw != "" {
+		c.Log.Output = raw
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayLogMaxSize)
+	if ok && raw != "" {
+		maxSize, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert log max size to int: %w", err)
+		}
+
+		c.Log.MaxSize = maxSize
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayLogMaxBackups)
+	if ok && raw != "" {
+		maxBackups, err := strconv.Atoi(raw)
+		if err != nil {
+			return c, fmt.Errorf("failed to convert log max backups to int: %w", err)
+		}
+
+		c.Log.MaxBackups = maxBackups
+	}
+
+	err := c.Log.Validate()
+	if err != nil {
+		return c, err
 	}
 
 	raw, ok = os.LookupEnv(pfStatusRelayInterfaces)
-	if !ok || raw == "" {
+	if ok && raw != "" {
+		c.Interfaces = splitList(raw)
+	}
+
+	if len(c.Interfaces) == 0 {
 		return c, fmt.Errorf("interfaces must be set")
 	}
 
-	pfs := strings.Split(raw, ",")
-	for i := range pfs {
-		pf := strings.TrimSpace(pfs[i])
-		if pf != "" {
-			c.Interfaces = append(c.Interfaces, pf)
+	raw, ok = os.LookupEnv(pfStatusRelayDetectors)
+	if ok && raw != "" {
+		c.Defaults.Detectors = nil
+		for _, name := range splitList(raw) {
+			c.Defaults.Detectors = append(c.Defaults.Detectors, PluginConfig{Name: name})
+		}
+	}
+
+	raw, ok = os.LookupEnv(pfStatusRelayHealth)
+	if ok && raw != "" {
+		c.Defaults.Health = raw
+	}
+
+	for _, pf := range c.Interfaces {
+		conf := c.PF(pf)
+		for _, p := range slices.Concat(conf.Detectors, conf.Actuators) {
+			if p.Name == "" {
+				return c, fmt.Errorf("detector or actuator without name for interface %s", pf)
+			}
+		}
+
+		ids := make(map[string]bool, len(conf.Detectors))
+		for _, d := range conf.Detectors {
+			if ids[d.Identifier()] {
+				return c, fmt.Errorf("duplicated detector id %s for interface %s", d.Identifier(), pf)
+			}
+			ids[d.Identifier()] = true
 		}
 	}
 
@@ -56,3 +530,16 @@ func ReadConfig() (Config, error) {
 
 	return c, nil
 }
+
+// splitList splits a comma separated list, trimming spaces and removing empty elements.
+func splitList(raw string) []string {
+	var list []string
+	for _, e := range strings.Split(raw, ",") {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			list = append(list, e)
+		}
+	}
+
+	return list
+}
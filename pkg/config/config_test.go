@@ -2,9 +2,12 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/openshift/pf-status-relay/pkg/log"
 )
 
 var _ = Describe("Config", func() {
@@ -14,6 +17,56 @@ var _ = Describe("Config", func() {
 
 		err = os.Unsetenv(pfStatusRelayPollingInterval)
 		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayConfigFile)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayDetectors)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayHealth)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayHoldDown)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayStateFile)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayShutdownPolicy)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayShutdownTimeout)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayActuationAttempts)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayActuationBackoff)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayActuationMaxBackoff)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayActuationVerify)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayActuationConcurrency)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayActuationRate)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayLinkDump)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Unsetenv(pfStatusRelayMinPollingInterval)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, env := range []string{pfStatusRelayHTTPAddress, pfStatusRelayStallTimeout, pfStatusRelayStatusSocket, pfStatusRelayLogLevel, pfStatusRelayLogFormat, pfStatusRelayLogOutput, pfStatusRelayLogMaxSize, pfStatusRelayLogMaxBackups} {
+			err = os.Unsetenv(env)
+			Expect(err).NotTo(HaveOccurred())
+		}
 	})
 
 	Context("ReadConfig", func() {
@@ -44,6 +97,32 @@ var _ = Describe("Config", func() {
 			// Validate the results.
 			Expect(c.Interfaces).To(Equal([]string{"eth0", "eth1"}))
 			Expect(c.PollingInterval).To(Equal(1000))
+			Expect(c.MinPollingInterval).To(Equal(1000))
+		})
+
+		It("should read the min polling interval", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayMinPollingInterval, "200")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.MinPollingInterval).To(Equal(200))
+		})
+
+		It("should return an error when the min polling interval is greater than the polling interval", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayMinPollingInterval, "2000")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(MatchError("min polling interval must be between 100 and the polling interval - current value: 2000"))
 		})
 
 		It("should return an error when the polling interval is smaller than 100", func() {
@@ -96,5 +175,459 @@ var _ = Describe("Config", func() {
 			// Validate the results.
 			Expect(c.Interfaces).To(Equal([]string{"eth0"}))
 		})
+
+		It("should read the config file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+			err := os.WriteFile(path, []byte(`interfaces: [eth0, eth1]
+pollingInterval: 200
+defaults:
+  detectors:
+  - name: lacp
+  - name: carrier
+pfs:
+  eth1:
+    detectors:
+    - name: lacp
+    actuators:
+    - name: vfstate
+      options:
+        key: value
+`), 0o600)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayConfigFile, path)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			// Validate the results.
+			Expect(c.Interfaces).To(Equal([]string{"eth0", "eth1"}))
+			Expect(c.PollingInterval).To(Equal(200))
+			Expect(c.PF("eth0")).To(Equal(PFConfig{
+				Detectors: []PluginConfig{{Name: "lacp"}, {Name: "carrier"}},
+				Actuators: []PluginConfig{{Name: "vfstate"}},
+			}))
+			Expect(c.PF("eth1")).To(Equal(PFConfig{
+				Detectors: []PluginConfig{{Name: "lacp"}},
+				Actuators: []PluginConfig{{Name: "vfstate", Options: map[string]string{"key": "value"}}},
+			}))
+		})
+
+		It("should override the config file with env vars", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+			err := os.WriteFile(path, []byte("interfaces: [eth0]\npollingInterval: 200\n"), 0o600)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayConfigFile, path)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayInterfaces, "eth1")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayDetectors, "lacp, carrier")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			// Validate the results.
+			Expect(c.Interfaces).To(Equal([]string{"eth1"}))
+			Expect(c.PollingInterval).To(Equal(200))
+			Expect(c.PF("eth1").Detectors).To(Equal([]PluginConfig{{Name: "lacp"}, {Name: "carrier"}}))
+		})
+
+		It("should return an error when the config file has unknown fields", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+			err := os.WriteFile(path, []byte("interfaces: [eth0]\nunknown: true\n"), 0o600)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayConfigFile, path)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error when detector ids are duplicated", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+			err := os.WriteFile(path, []byte(`interfaces: [eth0]
+defaults:
+  health: "lacp && !lacp"
+  detectors:
+  - name: lacp
+  - name: lacp
+`), 0o600)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayConfigFile, path)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(MatchError("duplicated detector id lacp for interface eth0"))
+		})
+
+		It("should read the vf selection", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+			err := os.WriteFile(path, []byte(`interfaces: [eth0, eth1]
+defaults:
+  vfs:
+    exclude:
+    - driver: vfio-pci
+pfs:
+  eth1:
+    vfs:
+      include:
+      - ids: "0-3"
+        trust: false
+`), 0o600)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayConfigFile, path)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			// Validate the results.
+			trust := false
+			Expect(c.PF("eth0").VFs).To(Equal(VFSelection{Exclude: []VFRule{{Driver: "vfio-pci"}}}))
+			Expect(c.PF("eth1").VFs).To(Equal(VFSelection{Include: []VFRule{{IDs: "0-3", Trust: &trust}}}))
+		})
+
+		It("should read the vf priority", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+			err := os.WriteFile(path, []byte(`interfaces: [eth0, eth1]
+defaults:
+  priority:
+    kubelet:
+      priority: 10
+      resources:
+        openshift.io/prod: 100
+pfs:
+  eth1:
+    priority:
+      ids:
+        "0-3": 50
+      macs:
+        "02:00:00:00:00:10": 100
+`), 0o600)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayConfigFile, path)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			// Validate the results.
+			Expect(c.PF("eth0").Priority).To(Equal(Priority{Kubelet: &KubeletPriority{Priority: 10, Resources: map[string]int{"openshift.io/prod": 100}}}))
+			Expect(c.PF("eth1").Priority).To(Equal(Priority{IDs: map[string]int{"0-3": 50}, MACs: map[string]int{"02:00:00:00:00:10": 100}}))
+		})
+
+		It("should read the state file", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.StateFile).To(Equal("/run/pf-status-relay/state.json"))
+
+			By("disabling the journal with an empty value")
+			err = os.Setenv(pfStatusRelayStateFile, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err = ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.StateFile).To(BeEmpty())
+		})
+
+		It("should read the shutdown policy", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayShutdownPolicy, "restoreIfUp")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayShutdownTimeout, "2000")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			// Validate the results.
+			Expect(c.ShutdownPolicy).To(Equal(ShutdownRestoreIfUp))
+			Expect(c.ShutdownTimeout).To(Equal(2000))
+		})
+
+		It("should read the actuation retry", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.ActuationAttempts).To(Equal(3))
+			Expect(c.ActuationBackoff).To(Equal(100))
+			Expect(c.ActuationMaxBackoff).To(Equal(1000))
+			Expect(c.ActuationVerify).To(BeTrue())
+
+			err = os.Setenv(pfStatusRelayActuationAttempts, "5")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayActuationBackoff, "0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayActuationMaxBackoff, "5000")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayActuationVerify, "false")
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err = ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.ActuationAttempts).To(Equal(5))
+			Expect(c.ActuationBackoff).To(Equal(0))
+			Expect(c.ActuationMaxBackoff).To(Equal(5000))
+			Expect(c.ActuationVerify).To(BeFalse())
+		})
+
+		It("should return an error when the actuation max backoff is lower than the backoff", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayActuationMaxBackoff, "50")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(MatchError("actuation max backoff must not be lower than the actuation backoff - current value: 50"))
+		})
+
+		It("should read the actuation queue", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayActuationConcurrency, "4")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayActuationRate, "50.5")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.ActuationConcurrency).To(Equal(4))
+			Expect(c.ActuationRate).To(Equal(50.5))
+		})
+
+		It("should return an error when the actuation rate is negative", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayActuationRate, "-1")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(MatchError("actuation rate must not be negative - current value: -1"))
+		})
+
+		It("should read the link dump", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.LinkDump).To(BeFalse())
+
+			err = os.Setenv(pfStatusRelayLinkDump, "true")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err = ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.LinkDump).To(BeTrue())
+		})
+
+		It("should return an error when the link dump is not a bool", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayLinkDump, "sometimes")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should read the http address", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayHTTPAddress, ":9100")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.HTTPAddress).To(Equal(":9100"))
+		})
+
+		It("should read the status socket", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayStatusSocket, "/run/pf-status-relay/status.sock")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.StatusSocket).To(Equal("/run/pf-status-relay/status.sock"))
+		})
+
+		It("should read the stall timeout", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.StallTimeout).To(Equal(30000))
+
+			err = os.Setenv(pfStatusRelayPollingInterval, "60000")
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err = ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.StallTimeout).To(Equal(1800000))
+
+			err = os.Unsetenv(pfStatusRelayPollingInterval)
+			Expect(err).NotTo(HaveOccurred())
+			err = os.Setenv(pfStatusRelayStallTimeout, "5000")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err = ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.StallTimeout).To(Equal(5000))
+		})
+
+		It("should return an error when the stall timeout is not greater than the polling interval", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayStallTimeout, "1000")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should read the log configuration", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Log).To(Equal(log.Config{Level: "info", Format: "json", Output: "stdout", MaxBackups: 3}))
+
+			err = os.Setenv(pfStatusRelayLogLevel, "warn, monitor=debug")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayLogFormat, "logfmt")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayLogOutput, "/var/log/pf-status-relay.log")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayLogMaxSize, "10")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err = ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Log).To(Equal(log.Config{
+				Level:      "warn",
+				Components: map[string]string{"monitor": "debug"},
+				Format:     "logfmt",
+				Output:     "/var/log/pf-status-relay.log",
+				MaxSize:    10,
+				MaxBackups: 3,
+			}))
+		})
+
+		It("should return an error when the log level of a component is not valid", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayLogLevel, "info,actuator=debug")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(MatchError(`unknown log component "actuator"`))
+		})
+
+		It("should return an error when there are no actuation attempts", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayActuationAttempts, "0")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(MatchError("actuation attempts must be at least 1 - current value: 0"))
+		})
+
+		It("should return an error when the shutdown policy is unknown", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayShutdownPolicy, "unknown")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(MatchError("shutdown policy must be one of leave, restore or restoreIfUp - current value: unknown"))
+		})
+
+		It("should read the hold down", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayHoldDown, "3000")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			c, err := ReadConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			// Validate the results.
+			Expect(c.HoldDown).To(Equal(3000))
+		})
+
+		It("should return an error when the hold down is negative", func() {
+			err := os.Setenv(pfStatusRelayInterfaces, "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Setenv(pfStatusRelayHoldDown, "-1")
+			Expect(err).NotTo(HaveOccurred())
+
+			// Call the function under test.
+			_, err = ReadConfig()
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
@@ -0,0 +1,240 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/bfd"
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+func init() {
+	Register("bfd", func(pf string, nl interfaces.Netlink, options map[string]string) (Detector, error) {
+		peer, err := netip.ParseAddr(options["peer"])
+		if err != nil || !peer.Is4() {
+			return nil, fmt.Errorf("bfd detector requires an IPv4 peer option")
+		}
+
+		b := &BFD{
+			conf: bfd.Config{
+				Peer:                  netip.AddrPortFrom(peer, bfd.Port),
+				DesiredMinTxInterval:  300 * time.Millisecond,
+				RequiredMinRxInterval: 300 * time.Millisecond,
+				DetectMultiplier:      3,
+			},
+			port: bfd.Port,
+		}
+
+		b.local = func() (netip.Addr, error) {
+			bond, err := bondOf(pf, nl)()
+			if err != nil {
+				return netip.Addr{}, err
+			}
+			return firstIPv4(bond)
+		}
+		if raw := options["local"]; raw != "" {
+			local, err := netip.ParseAddr(raw)
+			if err != nil || !local.Is4() {
+				return nil, fmt.Errorf("invalid bfd local address %q", raw)
+			}
+			b.local = func() (netip.Addr, error) {
+				return local, nil
+			}
+		}
+
+		for key, value := range map[string]*time.Duration{"tx": &b.conf.DesiredMinTxInterval, "rx": &b.conf.RequiredMinRxInterval} {
+			if raw, ok := options[key]; ok {
+				ms, err := strconv.Atoi(raw)
+				if err != nil || ms <= 0 {
+					return nil, fmt.Errorf("invalid bfd %s interval %q", key, raw)
+				}
+				*value = time.Duration(ms) * time.Millisecond
+			}
+		}
+
+		if raw, ok := options["multiplier"]; ok {
+			m, err := strconv.ParseUint(raw, 10, 8)
+			if err != nil || m == 0 {
+				return nil, fmt.Errorf("invalid bfd multiplier %q", raw)
+			}
+			b.conf.DetectMultiplier = uint8(m)
+		}
+
+		if raw, ok := options["port"]; ok {
+			port, err := strconv.ParseUint(raw, 10, 16)
+			if err != nil || port == 0 {
+				return nil, fmt.Errorf("invalid bfd port %q", raw)
+			}
+			b.port = uint16(port)
+			b.conf.Peer = netip.AddrPortFrom(peer, uint16(port))
+		}
+
+		return b, nil
+	})
+}
+
+// BFD reports a PF as healthy while a single-hop BFD session towards a peer, usually the ToR, is up. The PFs of a
+// bond share the session of the bond.
+type BFD struct {
+	conf  bfd.Config
+	port  uint16
+	local func() (netip.Addr, error)
+
+	mu      sync.Mutex
+	session *bfd.Session
+}
+
+// Name returns the name of the detector.
+func (b *BFD) Name() string {
+	return "bfd"
+}
+
+// Detect returns the state of the BFD session.
+func (b *BFD) Detect(netlink.Link) (Status, error) {
+	b.mu.Lock()
+	session := b.session
+	b.mu.Unlock()
+
+	if session == nil {
+		return Status{Reason: "bfd session is not started"}, nil
+	}
+
+	state, diagnostic := session.State()
+	if state != bfd.Up {
+		return Status{Reason: fmt.Sprintf("bfd session is %s: %s", state, diagnostic)}, nil
+	}
+
+	return Status{Healthy: true}, nil
+}
+
+// Run runs the BFD session until ctx is cancelled, restarting it when it fails.
+func (b *BFD) Run(ctx context.Context) {
+	for {
+		err := b.run(ctx)
+		if err != nil {
+			log.Log.Error("bfd session failed", "peer", b.conf.Peer, "error", err)
+		}
+
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			log.Log.Debug("ctx cancelled", "routine", "bfd")
+			return
+		}
+	}
+}
+
+func (b *BFD) run(ctx context.Context) error {
+	local, err := b.local()
+	if err != nil {
+		return fmt.Errorf("failed to resolve local address: %w", err)
+	}
+
+	conf := b.conf
+	conf.Local = netip.AddrPortFrom(local, b.port)
+	s, leave, err := join(conf)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	b.mu.Lock()
+	b.session = s.session
+	b.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return s.err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// sessions are the running BFD sessions by local address. The PFs of a bond resolve the same local address, which
+// can only be bound once, so their detectors share a session.
+var sessions = struct {
+	sync.Mutex
+	running map[netip.AddrPort]*shared
+}{running: make(map[netip.AddrPort]*shared)}
+
+// shared is a BFD session used by the detectors of one or more PFs.
+type shared struct {
+	session *bfd.Session
+	conf    bfd.Config
+	users   int
+	cancel  context.CancelFunc
+	// done is closed once the session stopped, with err set to the error it stopped with.
+	done chan struct{}
+	err  error
+}
+
+// join returns the running session with the given configuration, starting it when no detector uses it yet. The
+// returned function must be called once the session is not used anymore, and stops it after its last user left.
+func join(conf bfd.Config) (*shared, func(), error) {
+	sessions.Lock()
+	defer sessions.Unlock()
+
+	s, ok := sessions.running[conf.Local]
+	if ok && s.conf != conf {
+		return nil, nil, fmt.Errorf("bfd session on %s is already used with peer %s, set a distinct local option", conf.Local, s.conf.Peer)
+	}
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		s = &shared{session: bfd.NewSession(conf), conf: conf, cancel: cancel, done: make(chan struct{})}
+		sessions.running[conf.Local] = s
+		go func() {
+			s.err = s.session.Run(ctx)
+			sessions.Lock()
+			if sessions.running[conf.Local] == s {
+				delete(sessions.running, conf.Local)
+			}
+			sessions.Unlock()
+			close(s.done)
+		}()
+	}
+	s.users++
+
+	return s, func() {
+		sessions.Lock()
+		defer sessions.Unlock()
+
+		s.users--
+		if s.users == 0 {
+			if sessions.running[conf.Local] == s {
+				delete(sessions.running, conf.Local)
+			}
+			s.cancel()
+		}
+	}, nil
+}
+
+// firstIPv4 returns the first IPv4 address of the interface.
+func firstIPv4(name string) (netip.Addr, error) {
+	i, err := net.InterfaceByName(name)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	addrs, err := i.Addrs()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok {
+			if ip, ok := netip.AddrFromSlice(n.IP.To4()); ok {
+				return ip, nil
+			}
+		}
+	}
+
+	return netip.Addr{}, fmt.Errorf("interface %s has no IPv4 address", name)
+}
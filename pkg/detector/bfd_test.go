@@ -0,0 +1,111 @@
+package detector
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/bfd"
+)
+
+var _ = Describe("BFD", func() {
+	It("should report the state of the session with a peer", func() {
+		conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		port := conn.LocalAddr().(*net.UDPAddr).Port
+		Expect(conn.Close()).To(Succeed())
+
+		d, err := New("bfd", "test", nil, map[string]string{
+			"peer":  "127.0.0.2",
+			"local": "127.0.0.1",
+			"port":  strconv.Itoa(port),
+			"tx":    "20",
+			"rx":    "20",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		s, err := d.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(Equal(Status{Reason: "bfd session is not started"}))
+
+		peer := bfd.NewSession(bfd.Config{
+			Local:                 netip.AddrPortFrom(netip.MustParseAddr("127.0.0.2"), uint16(port)),
+			Peer:                  netip.AddrPortFrom(netip.MustParseAddr("127.0.0.1"), uint16(port)),
+			DesiredMinTxInterval:  20 * time.Millisecond,
+			RequiredMinRxInterval: 20 * time.Millisecond,
+			DetectMultiplier:      3,
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ctxPeer, cancelPeer := context.WithCancel(ctx)
+		go func() {
+			defer GinkgoRecover()
+			Expect(peer.Run(ctxPeer)).To(Succeed())
+		}()
+		go d.(Runner).Run(ctx)
+
+		detect := func() Status {
+			s, err := d.Detect(&netlink.Dummy{})
+			Expect(err).NotTo(HaveOccurred())
+			return s
+		}
+		// Control packets are sent every second at most until the session is up.
+		Eventually(detect, "5s", "10ms").Should(Equal(Status{Healthy: true}))
+
+		cancelPeer()
+		Eventually(detect, "1s", "10ms").Should(Equal(Status{Reason: "bfd session is Down: control detection time expired"}))
+	})
+
+	It("should share the session of the PFs of a bond", func() {
+		conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		port := conn.LocalAddr().(*net.UDPAddr).Port
+		Expect(conn.Close()).To(Succeed())
+
+		options := map[string]string{"peer": "127.0.0.2", "local": "127.0.0.1", "port": strconv.Itoa(port)}
+		first, err := New("bfd", "pf0", nil, options)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := New("bfd", "pf1", nil, options)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go first.(Runner).Run(ctx)
+		go second.(Runner).Run(ctx)
+
+		session := func(d Detector) func() *bfd.Session {
+			return func() *bfd.Session {
+				b := d.(*BFD)
+				b.mu.Lock()
+				defer b.mu.Unlock()
+				return b.session
+			}
+		}
+		Eventually(session(first)).ShouldNot(BeNil())
+		Eventually(session(second)).Should(BeIdenticalTo(session(first)()))
+
+		By("rejecting another peer on the same local address")
+		options["peer"] = "127.0.0.3"
+		third, err := New("bfd", "pf2", nil, options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(third.(*BFD).run(ctx)).To(MatchError(ContainSubstring("is already used with peer 127.0.0.2")))
+	})
+
+	It("should validate options", func() {
+		_, err := New("bfd", "test", nil, map[string]string{})
+		Expect(err).To(MatchError("bfd detector requires an IPv4 peer option"))
+
+		_, err = New("bfd", "test", nil, map[string]string{"peer": "10.0.0.1", "multiplier": "0"})
+		Expect(err).To(MatchError(`invalid bfd multiplier "0"`))
+
+		_, err = New("bfd", "test", nil, map[string]string{"peer": "10.0.0.1", "tx": "fast"})
+		Expect(err).To(MatchError(`invalid bfd tx interval "fast"`))
+	})
+})
@@ -0,0 +1,33 @@
+package detector
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+func init() {
+	Register("carrier", func(string, interfaces.Netlink, map[string]string) (Detector, error) {
+		return Carrier{}, nil
+	})
+}
+
+// Carrier reports a PF as healthy when its operational state is up.
+type Carrier struct{}
+
+// Name returns the name of the detector.
+func (Carrier) Name() string {
+	return "carrier"
+}
+
+// Detect inspects the operational state of the link.
+func (Carrier) Detect(link netlink.Link) (Status, error) {
+	state := link.Attrs().OperState
+	if state != netlink.OperUp {
+		return Status{Reason: fmt.Sprintf("operational state is %s", state)}, nil
+	}
+
+	return Status{Healthy: true}, nil
+}
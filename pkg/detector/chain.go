@@ -0,0 +1,48 @@
+package detector
+
+import (
+	"context"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+)
+
+// Chain is a detector that reports a PF as healthy only when all of its detectors do.
+type Chain []Detector
+
+// Name returns the names of the chained detectors.
+func (c Chain) Name() string {
+	names := make([]string, 0, len(c))
+	for _, d := range c {
+		names = append(names, d.Name())
+	}
+
+	return strings.Join(names, "&&")
+}
+
+// Run runs the detectors of the chain that collect their status in the background.
+func (c Chain) Run(ctx context.Context) {
+	Run(ctx, c...)
+}
+
+// Detect runs every detector of the chain. All detectors are evaluated so that the reason lists every failure.
+func (c Chain) Detect(link netlink.Link) (Status, error) {
+	status := Status{Healthy: true}
+	var reasons []string
+	for _, d := range c {
+		s, err := d.Detect(link)
+		if err != nil {
+			return Status{}, err
+		}
+
+		status.Warnings = append(status.Warnings, s.Warnings...)
+		status.mergeAttributes(d.Name(), s)
+		if !s.Healthy {
+			status.Healthy = false
+			reasons = append(reasons, d.Name()+": "+s.Reason)
+		}
+	}
+	status.Reason = strings.Join(reasons, "; ")
+
+	return status, nil
+}
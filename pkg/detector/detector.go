@@ -0,0 +1,128 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+// Status is the health of a PF as seen by a detector.
+type Status struct {
+	// Healthy indicates if the PF can be used by its VFs.
+	Healthy bool
+	// Reason explains why the PF is not healthy.
+	Reason string
+	// Warnings are conditions that do not affect health but should be reported.
+	Warnings []string
+	// Values are measurements that can be referenced by health expressions.
+	Values map[string]float64
+	// Attributes describe the PF as seen by the detector, e.g. its LLDP neighbor.
+	Attributes map[string]string
+}
+
+// mergeValues copies the values of a child status prefixed with its name.
+func (s *Status) mergeValues(prefix string, child Status) {
+	for k, v := range child.Values {
+		if s.Values == nil {
+			s.Values = make(map[string]float64)
+		}
+		s.Values[prefix+"."+k] = v
+	}
+}
+
+// mergeAttributes copies the attributes of a child status prefixed with its name.
+func (s *Status) mergeAttributes(prefix string, child Status) {
+	for k, v := range child.Attributes {
+		if s.Attributes == nil {
+			s.Attributes = make(map[string]string)
+		}
+		s.Attributes[prefix+"."+k] = v
+	}
+}
+
+// Detector determines the health of a PF.
+type Detector interface {
+	// Name returns the name of the detector.
+	Name() string
+	// Detect returns the health of the PF described by link. An error is returned
+	// when the health cannot be determined.
+	Detect(link netlink.Link) (Status, error)
+}
+
+// Publisher is implemented by detectors that publish values in their status.
+type Publisher interface {
+	// ValueNames returns the names of the values published by the detector.
+	ValueNames() []string
+}
+
+// Runner is implemented by detectors that collect the health of the PF in the background.
+type Runner interface {
+	// Run collects the health of the PF until ctx is cancelled.
+	Run(ctx context.Context)
+}
+
+// Run runs every detector that implements Runner until ctx is cancelled.
+func Run(ctx context.Context, detectors ...Detector) {
+	var wg sync.WaitGroup
+	for _, d := range detectors {
+		if r, ok := d.(Runner); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.Run(ctx)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// Factory creates a detector for the PF with the given name.
+type Factory func(pf string, nl interfaces.Netlink, options map[string]string) (Detector, error)
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Factory)
+)
+
+// Register makes a detector available by name. It panics if name is already registered.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic("detector: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New returns a detector registered with name for the given PF.
+func New(name, pf string, nl interfaces.Netlink, options map[string]string) (Detector, error) {
+	mu.RLock()
+	factory, ok := registry[name]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown detector %q", name)
+	}
+
+	return factory(pf, nl, options)
+}
+
+// Registered returns the sorted names of the registered detectors.
+func Registered() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,116 @@
+package detector
+
+import (
+	"maps"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+)
+
+type fake struct {
+	name   string
+	status Status
+}
+
+func (f fake) Name() string {
+	return f.name
+}
+
+func (f fake) Detect(netlink.Link) (Status, error) {
+	return f.status, nil
+}
+
+func (f fake) ValueNames() []string {
+	return slices.Collect(maps.Keys(f.status.Values))
+}
+
+var _ = Describe("Detector", func() {
+	Describe("Registry", func() {
+		It("should list the builtin detectors", func() {
+			Expect(Registered()).To(ContainElements("carrier", "lacp"))
+		})
+
+		It("should create a registered detector", func() {
+			d, err := New("lacp", "test", nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d.Name()).To(Equal("lacp"))
+		})
+
+		It("should return an error for an unknown detector", func() {
+			_, err := New("unknown", "test", nil, nil)
+			Expect(err).To(MatchError(`unknown detector "unknown"`))
+		})
+
+		It("should panic when a detector is registered twice", func() {
+			Expect(func() {
+				Register("lacp", nil)
+			}).To(Panic())
+		})
+	})
+
+	Describe("LACP", func() {
+		It("should return healthy with a warning when lacp is up with slow rate", func() {
+			link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Slave: &netlink.BondSlave{
+				AdActorOperPortState:   60,
+				AdPartnerOperPortState: 60,
+			}}}
+
+			s, err := LACP{}.Detect(link)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s).To(Equal(Status{Healthy: true, Warnings: []string{"pf is using slow lacp rate"}}))
+		})
+
+		It("should return not healthy when lacp is down", func() {
+			link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Slave: &netlink.BondSlave{
+				AdActorOperPortState:   60,
+				AdPartnerOperPortState: 0,
+			}}}
+
+			s, err := LACP{}.Detect(link)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s).To(Equal(Status{Reason: "lacp is down"}))
+		})
+
+		It("should return an error when the link has no slave attribute", func() {
+			_, err := LACP{}.Detect(&netlink.Dummy{})
+			Expect(err).To(MatchError("interface has no slave attribute"))
+		})
+	})
+
+	Describe("Carrier", func() {
+		It("should return healthy when the link is up", func() {
+			s, err := Carrier{}.Detect(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUp}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Healthy).To(BeTrue())
+		})
+
+		It("should return not healthy when the link is down", func() {
+			s, err := Carrier{}.Detect(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperDown}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s).To(Equal(Status{Reason: "operational state is down"}))
+		})
+	})
+
+	Describe("Chain", func() {
+		It("should be healthy when all detectors are healthy", func() {
+			c := Chain{fake{name: "a", status: Status{Healthy: true}}, fake{name: "b", status: Status{Healthy: true}}}
+			s, err := c.Detect(&netlink.Dummy{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Healthy).To(BeTrue())
+			Expect(c.Name()).To(Equal("a&&b"))
+		})
+
+		It("should report every failing detector", func() {
+			c := Chain{
+				fake{name: "a", status: Status{Reason: "a failed"}},
+				fake{name: "b", status: Status{Healthy: true, Warnings: []string{"warning"}}},
+				fake{name: "c", status: Status{Reason: "c failed"}},
+			}
+			s, err := c.Detect(&netlink.Dummy{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s).To(Equal(Status{Reason: "a: a failed; c: c failed", Warnings: []string{"warning"}}))
+		})
+	})
+})
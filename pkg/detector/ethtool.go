@@ -0,0 +1,126 @@
+package detector
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/ethtool"
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+)
+
+func init() {
+	Register("ethtool", func(_ string, _ interfaces.Netlink, options map[string]string) (Detector, error) {
+		var counters []string
+		for _, c := range strings.Split(options["counters"], ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				counters = append(counters, c)
+			}
+		}
+		if len(counters) == 0 {
+			return nil, fmt.Errorf("ethtool detector requires the counters option")
+		}
+
+		threshold, err := strconv.ParseFloat(options["threshold"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("ethtool detector requires a numeric threshold option: %w", err)
+		}
+
+		return NewEthtool(StatsFunc(ethtool.Stats), counters, threshold), nil
+	})
+}
+
+// StatsProvider returns the NIC specific statistics of an interface.
+type StatsProvider interface {
+	Stats(name string) (map[string]uint64, error)
+}
+
+// StatsFunc is an adapter to use a function as a StatsProvider.
+type StatsFunc func(name string) (map[string]uint64, error)
+
+// Stats calls f(name).
+func (f StatsFunc) Stats(name string) (map[string]uint64, error) {
+	return f(name)
+}
+
+// Ethtool reports a PF as degraded when the per second rate of the sum of some of its ethtool counters
+// (i.e. CRC/FCS errors) is greater than a threshold.
+type Ethtool struct {
+	provider  StatsProvider
+	counters  []string
+	threshold float64
+
+	last     map[string]uint64
+	lastTime time.Time
+	now      func() time.Time
+}
+
+// NewEthtool returns an Ethtool detector that samples counters from provider.
+func NewEthtool(provider StatsProvider, counters []string, threshold float64) *Ethtool {
+	return &Ethtool{
+		provider:  provider,
+		counters:  counters,
+		threshold: threshold,
+		now:       time.Now,
+	}
+}
+
+// ValueNames returns the names of the values published by the detector: the rate of every counter and their sum.
+func (e *Ethtool) ValueNames() []string {
+	return append(slices.Clone(e.counters), "rate")
+}
+
+// Name returns the name of the detector.
+func (e *Ethtool) Name() string {
+	return "ethtool"
+}
+
+// Detect samples the counters and computes their rates since the previous sample. The rate of each counter is
+// published as a value named after it, and the sum as "rate".
+func (e *Ethtool) Detect(link netlink.Link) (Status, error) {
+	stats, err := e.provider.Stats(link.Attrs().Name)
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to read ethtool statistics: %w", err)
+	}
+
+	now := e.now()
+	sample := make(map[string]uint64, len(e.counters))
+	for _, c := range e.counters {
+		v, ok := stats[c]
+		if !ok {
+			return Status{}, fmt.Errorf("ethtool counter %s not found", c)
+		}
+		sample[c] = v
+	}
+
+	last, lastTime := e.last, e.lastTime
+	e.last, e.lastTime = sample, now
+
+	// The first sample has no rate, its values are published as 0 so that expressions can be evaluated.
+	elapsed := now.Sub(lastTime).Seconds()
+	values := make(map[string]float64, len(e.counters)+1)
+	total := 0.0
+	for _, c := range e.counters {
+		rate := 0.0
+		// Counters that went backwards were reset, skip them for this sample.
+		if last != nil && elapsed > 0 && sample[c] >= last[c] {
+			rate = float64(sample[c]-last[c]) / elapsed
+		}
+		values[c] = rate
+		total += rate
+	}
+	values["rate"] = total
+
+	if total > e.threshold {
+		return Status{
+			Reason: "error rate above threshold",
+			Values: values,
+		}, nil
+	}
+
+	return Status{Healthy: true, Values: values}, nil
+}
@@ -0,0 +1,107 @@
+package detector
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+)
+
+type fakeStats map[string]uint64
+
+func (f fakeStats) Stats(string) (map[string]uint64, error) {
+	return f, nil
+}
+
+var _ = Describe("Ethtool", func() {
+	var (
+		stats fakeStats
+		now   time.Time
+		e     *Ethtool
+		link  *netlink.Dummy
+	)
+
+	BeforeEach(func() {
+		stats = fakeStats{"rx_crc_errors": 0, "rx_fcs_errors": 0, "rx_packets": 1000}
+		now = time.Unix(0, 0)
+		e = NewEthtool(stats, []string{"rx_crc_errors", "rx_fcs_errors"}, 100)
+		e.now = func() time.Time {
+			return now
+		}
+		link = &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test"}}
+	})
+
+	It("should be healthy and publish zero rates on the first sample", func() {
+		s, err := e.Detect(link)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(Equal(Status{Healthy: true, Values: map[string]float64{"rx_crc_errors": 0, "rx_fcs_errors": 0, "rate": 0}}))
+	})
+
+	It("should be healthy while the rate is below the threshold", func() {
+		_, err := e.Detect(link)
+		Expect(err).NotTo(HaveOccurred())
+
+		stats["rx_crc_errors"] = 100
+		stats["rx_fcs_errors"] = 50
+		now = now.Add(2 * time.Second)
+
+		s, err := e.Detect(link)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(Equal(Status{Healthy: true, Values: map[string]float64{"rx_crc_errors": 50, "rx_fcs_errors": 25, "rate": 75}}))
+	})
+
+	It("should be degraded when the rate is above the threshold", func() {
+		_, err := e.Detect(link)
+		Expect(err).NotTo(HaveOccurred())
+
+		stats["rx_crc_errors"] = 300
+		now = now.Add(time.Second)
+
+		s, err := e.Detect(link)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Healthy).To(BeFalse())
+		Expect(s.Reason).To(Equal("error rate above threshold"))
+		Expect(s.Values).To(HaveKeyWithValue("rate", 300.0))
+
+		By("recovering when errors stop")
+		now = now.Add(time.Second)
+
+		s, err = e.Detect(link)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Healthy).To(BeTrue())
+	})
+
+	It("should ignore counters that were reset", func() {
+		stats["rx_crc_errors"] = 1000
+		_, err := e.Detect(link)
+		Expect(err).NotTo(HaveOccurred())
+
+		stats["rx_crc_errors"] = 10
+		now = now.Add(time.Second)
+
+		s, err := e.Detect(link)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Healthy).To(BeTrue())
+		Expect(s.Values["rx_crc_errors"]).To(BeZero())
+	})
+
+	It("should return an error when a counter is missing", func() {
+		delete(stats, "rx_fcs_errors")
+
+		_, err := e.Detect(link)
+		Expect(err).To(MatchError("ethtool counter rx_fcs_errors not found"))
+	})
+
+	It("should validate options", func() {
+		_, err := New("ethtool", "test", nil, map[string]string{"threshold": "10"})
+		Expect(err).To(MatchError("ethtool detector requires the counters option"))
+
+		_, err = New("ethtool", "test", nil, map[string]string{"counters": "rx_crc_errors"})
+		Expect(err).To(HaveOccurred())
+
+		d, err := New("ethtool", "test", nil, map[string]string{"counters": "rx_crc_errors", "threshold": "10"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.Name()).To(Equal("ethtool"))
+	})
+})
@@ -0,0 +1,685 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+// Expression is a detector that evaluates a boolean expression over the results of other detectors.
+//
+// The expression supports the following terms:
+//   - detector ids, which are true when the detector reports the PF as healthy (i.e. "lacp").
+//   - comparisons between numbers and values published by detectors, which are referenced either as "key" or
+//     as "id.key" (i.e. "ethtool.rate > 100/s"). The "/s" unit is accepted for readability.
+//   - "atleast(n, term, ...)", which is true when at least n terms are true.
+//   - "!", "&&", "||" and parentheses.
+type Expression struct {
+	source    string
+	root      node
+	ids       []string
+	detectors map[string]Detector
+}
+
+// NewExpression parses source and returns an Expression detector over the detectors indexed by id.
+func NewExpression(source string, detectors map[string]Detector) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+
+	ids := make([]string, 0, len(detectors))
+	for id := range detectors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	e := &Expression{source: source, root: root, ids: ids, detectors: detectors}
+	if err := e.boolean(root); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Name returns the source of the expression.
+func (e *Expression) Name() string {
+	return e.source
+}
+
+// Run runs the detectors of the expression that collect their status in the background.
+func (e *Expression) Run(ctx context.Context) {
+	detectors := make([]Detector, 0, len(e.ids))
+	for _, id := range e.ids {
+		detectors = append(detectors, e.detectors[id])
+	}
+	Run(ctx, detectors...)
+}
+
+// Detect runs every detector and evaluates the expression. The reason lists the terms that made the expression false,
+// and the values published by the detectors are returned qualified by their id, so that the reason does not change
+// along with them.
+func (e *Expression) Detect(link netlink.Link) (Status, error) {
+	results := make(map[string]Status, len(e.detectors))
+	status := Status{}
+	for _, id := range e.ids {
+		s, err := e.detectors[id].Detect(link)
+		if err != nil {
+			return Status{}, fmt.Errorf("%s: %w", id, err)
+		}
+		results[id] = s
+		status.Warnings = append(status.Warnings, s.Warnings...)
+		status.mergeAttributes(id, s)
+		status.mergeValues(id, s)
+	}
+
+	v, reasons, err := e.root.eval(&env{ids: e.ids, results: results})
+	if err != nil {
+		return Status{}, err
+	}
+	if v.kind != kindBool {
+		return Status{}, fmt.Errorf("expression %q is not boolean", e.source)
+	}
+
+	status.Healthy = v.b
+	if !status.Healthy {
+		status.Reason = strings.Join(reasons, "; ")
+		log.Log.Debug("health expression is false", "expression", e.source, "interface", link.Attrs().Name, "values", status.Values)
+	}
+
+	return status, nil
+}
+
+// validate verifies that every identifier can be resolved against the configured detectors, and returns the kind of
+// n. Identifiers used as boolean terms must be detector ids, while identifiers compared with numbers are values
+// published by detectors, which must be unambiguous when unqualified.
+func (e *Expression) validate(n node, numeric bool) (kind, error) {
+	switch n := n.(type) {
+	case *identNode:
+		if _, ok := e.detectors[n.name]; ok {
+			return kindBool, nil
+		}
+		if !numeric {
+			return 0, fmt.Errorf("unknown detector %q", n.name)
+		}
+		if id, key, ok := strings.Cut(n.name, "."); ok {
+			d, ok := e.detectors[id]
+			if !ok {
+				return 0, fmt.Errorf("unknown detector %q", id)
+			}
+			if !slices.Contains(valueNames(d), key) {
+				return 0, fmt.Errorf("detector %s has no value %q", id, key)
+			}
+			return kindNumber, nil
+		}
+
+		var found []string
+		for _, id := range e.ids {
+			if slices.Contains(valueNames(e.detectors[id]), n.name) {
+				found = append(found, id)
+			}
+		}
+		switch len(found) {
+		case 0:
+			return 0, fmt.Errorf("unknown identifier %q", n.name)
+		case 1:
+			return kindNumber, nil
+		default:
+			return 0, fmt.Errorf("identifier %q is ambiguous, use one of %s", n.name, strings.Join(qualify(found, n.name), ", "))
+		}
+	case *numberNode:
+		return kindNumber, nil
+	case *notNode:
+		return kindBool, e.boolean(n.x)
+	case *binaryNode:
+		if !n.comparison() {
+			if err := e.boolean(n.x); err != nil {
+				return 0, err
+			}
+			return kindBool, e.boolean(n.y)
+		}
+		for _, t := range []node{n.x, n.y} {
+			k, err := e.validate(t, true)
+			if err != nil {
+				return 0, err
+			}
+			if k != kindNumber {
+				return 0, fmt.Errorf("%s compares non numeric terms", n)
+			}
+		}
+	case *atLeastNode:
+		for _, t := range n.terms {
+			if err := e.boolean(t); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return kindBool, nil
+}
+
+// boolean validates n and verifies that it is a boolean term.
+func (e *Expression) boolean(n node) error {
+	k, err := e.validate(n, false)
+	if err != nil {
+		return err
+	}
+	if k != kindBool {
+		return fmt.Errorf("%s is not boolean", n)
+	}
+
+	return nil
+}
+
+// valueNames returns the names of the values published by d.
+func valueNames(d Detector) []string {
+	p, ok := d.(Publisher)
+	if !ok {
+		return nil
+	}
+
+	return p.ValueNames()
+}
+
+type kind int
+
+const (
+	kindBool kind = iota
+	kindNumber
+)
+
+type value struct {
+	kind kind
+	b    bool
+	n    float64
+}
+
+type env struct {
+	ids     []string
+	results map[string]Status
+}
+
+// lookup resolves an identifier to the health of a detector or to a value published by a detector.
+func (e *env) lookup(name string) (value, string, error) {
+	if s, ok := e.results[name]; ok {
+		return value{kind: kindBool, b: s.Healthy}, s.Reason, nil
+	}
+
+	if id, key, ok := strings.Cut(name, "."); ok {
+		if s, ok := e.results[id]; ok {
+			if v, ok := s.Values[key]; ok {
+				return value{kind: kindNumber, n: v}, "", nil
+			}
+			return value{}, "", fmt.Errorf("detector %s has no value %q", id, key)
+		}
+	}
+
+	var found []string
+	var v float64
+	for _, id := range e.ids {
+		if n, ok := e.results[id].Values[name]; ok {
+			found = append(found, id)
+			v = n
+		}
+	}
+	switch len(found) {
+	case 0:
+		return value{}, "", fmt.Errorf("unknown identifier %q", name)
+	case 1:
+		return value{kind: kindNumber, n: v}, "", nil
+	default:
+		return value{}, "", fmt.Errorf("identifier %q is ambiguous, use one of %s", name, strings.Join(qualify(found, name), ", "))
+	}
+}
+
+func qualify(ids []string, key string) []string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, id+"."+key)
+	}
+
+	return names
+}
+
+type node interface {
+	// eval returns the value of the node and, when it is false, the reasons.
+	eval(e *env) (value, []string, error)
+	String() string
+}
+
+type identNode struct {
+	name string
+}
+
+func (n *identNode) eval(e *env) (value, []string, error) {
+	v, reason, err := e.lookup(n.name)
+	if err != nil {
+		return value{}, nil, err
+	}
+	if v.kind == kindBool && !v.b {
+		if reason == "" {
+			return v, []string{n.name}, nil
+		}
+		return v, []string{n.name + ": " + reason}, nil
+	}
+
+	return v, nil, nil
+}
+
+func (n *identNode) String() string {
+	return n.name
+}
+
+type numberNode struct {
+	n    float64
+	text string
+}
+
+func (n *numberNode) eval(*env) (value, []string, error) {
+	return value{kind: kindNumber, n: n.n}, nil, nil
+}
+
+func (n *numberNode) String() string {
+	return n.text
+}
+
+type boolNode struct {
+	b bool
+}
+
+func (n *boolNode) eval(*env) (value, []string, error) {
+	if !n.b {
+		return value{kind: kindBool}, []string{"false"}, nil
+	}
+
+	return value{kind: kindBool, b: true}, nil, nil
+}
+
+func (n *boolNode) String() string {
+	return strconv.FormatBool(n.b)
+}
+
+type notNode struct {
+	x node
+}
+
+func (n *notNode) eval(e *env) (value, []string, error) {
+	v, _, err := evalBool(n.x, e)
+	if err != nil {
+		return value{}, nil, err
+	}
+	if !v {
+		return value{kind: kindBool, b: true}, nil, nil
+	}
+
+	return value{kind: kindBool}, []string{n.String() + " is false"}, nil
+}
+
+func (n *notNode) String() string {
+	if _, ok := n.x.(*binaryNode); ok {
+		if s := n.x.String(); !strings.HasPrefix(s, "(") {
+			return "!(" + s + ")"
+		}
+	}
+
+	return "!" + n.x.String()
+}
+
+type binaryNode struct {
+	op   string
+	x, y node
+}
+
+func (n *binaryNode) eval(e *env) (value, []string, error) {
+	switch n.op {
+	case "&&", "||":
+		x, xr, err := evalBool(n.x, e)
+		if err != nil {
+			return value{}, nil, err
+		}
+		y, yr, err := evalBool(n.y, e)
+		if err != nil {
+			return value{}, nil, err
+		}
+		if n.op == "&&" {
+			return value{kind: kindBool, b: x && y}, append(xr, yr...), nil
+		}
+		if x || y {
+			return value{kind: kindBool, b: true}, nil, nil
+		}
+		return value{kind: kindBool}, append(xr, yr...), nil
+	}
+
+	x, _, err := n.x.eval(e)
+	if err != nil {
+		return value{}, nil, err
+	}
+	y, _, err := n.y.eval(e)
+	if err != nil {
+		return value{}, nil, err
+	}
+	if x.kind != kindNumber || y.kind != kindNumber {
+		return value{}, nil, fmt.Errorf("%s compares non numeric terms", n)
+	}
+
+	var b bool
+	switch n.op {
+	case ">":
+		b = x.n > y.n
+	case ">=":
+		b = x.n >= y.n
+	case "<":
+		b = x.n < y.n
+	case "<=":
+		b = x.n <= y.n
+	case "==":
+		b = x.n == y.n
+	case "!=":
+		b = x.n != y.n
+	}
+	if b {
+		return value{kind: kindBool, b: true}, nil, nil
+	}
+
+	return value{kind: kindBool}, []string{n.String() + " is false"}, nil
+}
+
+func (n *binaryNode) comparison() bool {
+	return n.op != "&&" && n.op != "||"
+}
+
+func (n *binaryNode) String() string {
+	if !n.comparison() {
+		return "(" + n.x.String() + " " + n.op + " " + n.y.String() + ")"
+	}
+
+	return n.x.String() + " " + n.op + " " + n.y.String()
+}
+
+type atLeastNode struct {
+	n     int
+	terms []node
+}
+
+func (n *atLeastNode) eval(e *env) (value, []string, error) {
+	count := 0
+	var reasons []string
+	for _, t := range n.terms {
+		b, r, err := evalBool(t, e)
+		if err != nil {
+			return value{}, nil, err
+		}
+		if b {
+			count++
+		}
+		reasons = append(reasons, r...)
+	}
+	if count >= n.n {
+		return value{kind: kindBool, b: true}, nil, nil
+	}
+
+	return value{kind: kindBool}, append([]string{fmt.Sprintf("%s is false (%d of %d)", n, count, len(n.terms))}, reasons...), nil
+}
+
+func (n *atLeastNode) String() string {
+	terms := make([]string, 0, len(n.terms))
+	for _, t := range n.terms {
+		terms = append(terms, t.String())
+	}
+
+	return fmt.Sprintf("atleast(%d, %s)", n.n, strings.Join(terms, ", "))
+}
+
+func evalBool(n node, e *env) (bool, []string, error) {
+	v, reasons, err := n.eval(e)
+	if err != nil {
+		return false, nil, err
+	}
+	if v.kind != kindBool {
+		return false, nil, fmt.Errorf("%s is not boolean", n)
+	}
+
+	return v.b, reasons, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"&&", "||", ">=", "<=", "==", "!=", ">", "<", "!", "(", ")", ","}
+
+// lex splits the source of an expression into tokens.
+func lex(source string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(source) {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(source) && isIdent(rune(source[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[start:i], pos: start})
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(source) && (unicode.IsDigit(rune(source[i])) || source[i] == '.') {
+				i++
+			}
+			if strings.HasPrefix(source[i:], "/s") {
+				i += 2
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[start:i], pos: start})
+		default:
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+func isIdent(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.' || c == '-'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) expect(text string) error {
+	t := p.next()
+	if t.kind != tokenOp || t.text != text {
+		return fmt.Errorf("expected %q at position %d", text, t.pos)
+	}
+
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "||" {
+		p.next()
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: "||", x: x, y: y}
+	}
+
+	return x, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOp && p.peek().text == "&&" {
+		p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: "&&", x: x, y: y}
+	}
+
+	return x, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokenOp && p.peek().text == "!" {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{x: x}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind == tokenOp {
+		switch t.text {
+		case ">", ">=", "<", "<=", "==", "!=":
+			p.next()
+			y, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return &binaryNode{op: t.text, x: x, y: y}, nil
+		}
+	}
+
+	return x, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		n, err := strconv.ParseFloat(strings.TrimSuffix(t.text, "/s"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+		return &numberNode{n: n, text: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			return &boolNode{b: t.text == "true"}, nil
+		case "atleast":
+			return p.parseAtLeast()
+		}
+		return &identNode{name: t.text}, nil
+	case tokenOp:
+		if t.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *parser) parseAtLeast() (node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	t := p.next()
+	n, err := strconv.Atoi(t.text)
+	if t.kind != tokenNumber || err != nil || n < 0 {
+		return nil, fmt.Errorf("atleast expects a count at position %d", t.pos)
+	}
+
+	var terms []node
+	for p.peek().kind == tokenOp && p.peek().text == "," {
+		p.next()
+		term, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if n > len(terms) {
+		return nil, fmt.Errorf("atleast expects at least %d terms", n)
+	}
+
+	return &atLeastNode{n: n, terms: terms}, nil
+}
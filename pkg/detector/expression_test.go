@@ -0,0 +1,82 @@
+package detector
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+)
+
+var _ = Describe("Expression", func() {
+	var detectors map[string]Detector
+
+	BeforeEach(func() {
+		detectors = map[string]Detector{
+			"lacp":    fake{name: "lacp", status: Status{Healthy: true}},
+			"carrier": fake{name: "carrier", status: Status{Reason: "operational state is down"}},
+			"probe":   fake{name: "probe", status: Status{Healthy: true}},
+			"stats":   fake{name: "stats", status: Status{Healthy: true, Values: map[string]float64{"crc_rate": 250, "drops": 3}}},
+			"other":   fake{name: "other", status: Status{Healthy: true, Values: map[string]float64{"drops": 7}}},
+		}
+	})
+
+	DescribeTable("evaluation",
+		func(source string, healthy bool, reason string) {
+			e, err := NewExpression(source, detectors)
+			Expect(err).NotTo(HaveOccurred())
+
+			s, err := e.Detect(&netlink.Dummy{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Healthy).To(Equal(healthy))
+			Expect(s.Reason).To(Equal(reason))
+		},
+		Entry("detector id", "lacp", true, ""),
+		Entry("failing detector id", "lacp && carrier", false, "carrier: operational state is down"),
+		Entry("or", "lacp || carrier", true, ""),
+		Entry("negated comparison", "lacp && !(crc_rate > 100/s)", false, "!(crc_rate > 100/s) is false"),
+		Entry("comparison", "crc_rate <= 100/s", false, "crc_rate <= 100/s is false"),
+		Entry("qualified value", "other.drops < 5", false, "other.drops < 5 is false"),
+		Entry("atleast satisfied", "atleast(2, lacp, carrier, probe)", true, ""),
+		Entry("atleast not satisfied", "atleast(2, lacp, carrier, crc_rate < 100)", false,
+			"atleast(2, lacp, carrier, crc_rate < 100) is false (1 of 3); carrier: operational state is down; crc_rate < 100 is false"),
+		Entry("precedence", "carrier && lacp || probe", true, ""),
+	)
+
+	It("should keep the reason when the values change", func() {
+		e, err := NewExpression("stats.crc_rate < 100", detectors)
+		Expect(err).NotTo(HaveOccurred())
+
+		s, err := e.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Reason).To(Equal("stats.crc_rate < 100 is false"))
+		Expect(s.Values).To(HaveKeyWithValue("stats.crc_rate", 250.0))
+
+		detectors["stats"] = fake{name: "stats", status: Status{Healthy: true, Values: map[string]float64{"crc_rate": 300, "drops": 3}}}
+		s, err = e.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Reason).To(Equal("stats.crc_rate < 100 is false"))
+		Expect(s.Values).To(HaveKeyWithValue("stats.crc_rate", 300.0))
+	})
+
+	DescribeTable("invalid expressions",
+		func(source string, message string) {
+			_, err := NewExpression(source, detectors)
+			Expect(err).To(MatchError(message))
+		},
+		Entry("unknown detector", "lacp && unknown", `unknown detector "unknown"`),
+		Entry("unknown qualified detector", "unknown.drops > 1", `unknown detector "unknown"`),
+		Entry("unknown value", "lacp && crc_errors > 1", `unknown identifier "crc_errors"`),
+		Entry("unknown qualified value", "stats.crc_errors > 1", `detector stats has no value "crc_errors"`),
+		Entry("ambiguous value", "drops < 10", `identifier "drops" is ambiguous, use one of other.drops, stats.drops`),
+		Entry("detector compared with a number", "lacp > 5", "lacp > 5 compares non numeric terms"),
+		Entry("detectors compared together", "lacp == carrier", "lacp == carrier compares non numeric terms"),
+		Entry("number used as a boolean", "5 && lacp", "5 is not boolean"),
+		Entry("negated number", "!crc_rate", `unknown detector "crc_rate"`),
+		Entry("number in atleast", "atleast(1, lacp, 5)", "5 is not boolean"),
+		Entry("number as expression", "5", "5 is not boolean"),
+		Entry("unbalanced parentheses", "(lacp && carrier", `expected ")" at position 16`),
+		Entry("trailing tokens", "lacp carrier", `unexpected "carrier" at position 5`),
+		Entry("invalid character", "lacp & carrier", `unexpected character '&' at position 5`),
+		Entry("atleast with too many required terms", "atleast(3, lacp, carrier)", "atleast expects at least 3 terms"),
+		Entry("empty expression", "", "unexpected end of expression"),
+	)
+})
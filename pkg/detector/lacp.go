@@ -0,0 +1,48 @@
+package detector
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+	"github.com/openshift/pf-status-relay/pkg/lacp/flags"
+)
+
+func init() {
+	Register("lacp", func(string, interfaces.Netlink, map[string]string) (Detector, error) {
+		return LACP{}, nil
+	})
+}
+
+// LACP reports a PF as healthy when the LACP protocol is up on both actor and partner.
+type LACP struct{}
+
+// Name returns the name of the detector.
+func (LACP) Name() string {
+	return "lacp"
+}
+
+// Detect inspects the LACP flags of the bond slave.
+func (LACP) Detect(link netlink.Link) (Status, error) {
+	slave := link.Attrs().Slave
+	if slave == nil {
+		return Status{}, fmt.Errorf("interface has no slave attribute")
+	}
+
+	s, ok := slave.(*netlink.BondSlave)
+	if !ok {
+		return Status{}, fmt.Errorf("interface does not have BondSlave type on Slave attribute")
+	}
+
+	if !flags.IsProtocolUp(s) {
+		return Status{Reason: "lacp is down"}, nil
+	}
+
+	status := Status{Healthy: true}
+	if !flags.IsFastRate(s) {
+		status.Warnings = append(status.Warnings, "pf is using slow lacp rate")
+	}
+
+	return status, nil
+}
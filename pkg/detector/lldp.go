@@ -0,0 +1,124 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+	"github.com/openshift/pf-status-relay/pkg/lldp"
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+func init() {
+	Register("lldp", func(pf string, _ interfaces.Netlink, options map[string]string) (Detector, error) {
+		l := NewLLDP(pf)
+		l.expected = lldp.Neighbor{
+			ChassisID:  options["chassisID"],
+			PortID:     options["portID"],
+			SystemName: options["systemName"],
+		}
+
+		switch options["failOnLoss"] {
+		case "", "false":
+		case "true":
+			l.failOnLoss = true
+		default:
+			return nil, fmt.Errorf("invalid lldp failOnLoss %q", options["failOnLoss"])
+		}
+
+		return l, nil
+	})
+}
+
+// LLDP tracks the LLDP neighbor of a PF. It optionally reports the PF as down when the neighbor is lost or does not
+// match the expected one. No decision is taken until the first LLDPDU is received.
+type LLDP struct {
+	iface      string
+	expected   lldp.Neighbor
+	failOnLoss bool
+	now        func() time.Time
+
+	mu       sync.Mutex
+	neighbor *lldp.Neighbor
+	lastSeen time.Time
+}
+
+// NewLLDP returns an LLDP detector that listens on iface.
+func NewLLDP(iface string) *LLDP {
+	return &LLDP{iface: iface, now: time.Now}
+}
+
+// Name returns the name of the detector.
+func (l *LLDP) Name() string {
+	return "lldp"
+}
+
+// Detect compares the last neighbor with the expected one. The neighbor is published in the attributes.
+func (l *LLDP) Detect(netlink.Link) (Status, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.neighbor == nil {
+		return Status{Healthy: true}, nil
+	}
+
+	n := *l.neighbor
+	attrs := n.Attributes()
+	if l.now().Sub(l.lastSeen) > n.TTL {
+		attrs["lost"] = "true"
+		if l.failOnLoss {
+			return Status{Reason: fmt.Sprintf("lldp neighbor %s lost", n.ChassisID), Attributes: attrs}, nil
+		}
+		return Status{Healthy: true, Attributes: attrs}, nil
+	}
+
+	var mismatches []string
+	for _, f := range []struct{ name, got, want string }{
+		{"chassis id", n.ChassisID, l.expected.ChassisID},
+		{"port id", n.PortID, l.expected.PortID},
+		{"system name", n.SystemName, l.expected.SystemName},
+	} {
+		if f.want != "" && f.got != f.want {
+			mismatches = append(mismatches, fmt.Sprintf("%s is %q, expected %q", f.name, f.got, f.want))
+		}
+	}
+	if len(mismatches) > 0 {
+		return Status{Reason: "unexpected lldp neighbor: " + strings.Join(mismatches, ", "), Attributes: attrs}, nil
+	}
+
+	return Status{Healthy: true, Attributes: attrs}, nil
+}
+
+// Run listens for LLDPDUs until ctx is cancelled, restarting the listener when it fails.
+func (l *LLDP) Run(ctx context.Context) {
+	for {
+		err := lldp.Listen(ctx, l.iface, l.update)
+		if err != nil {
+			log.Log.Error("failed to listen for lldp frames", "interface", l.iface, "error", err)
+		}
+
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			log.Log.Debug("ctx cancelled", "routine", "lldp")
+			return
+		}
+	}
+}
+
+// update stores the last received neighbor.
+func (l *LLDP) update(n lldp.Neighbor) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.neighbor == nil || l.neighbor.ChassisID != n.ChassisID || l.neighbor.PortID != n.PortID {
+		log.Log.Info("lldp neighbor detected", "interface", l.iface, "chassis id", n.ChassisID, "port id", n.PortID, "system name", n.SystemName)
+	}
+	l.neighbor = &n
+	l.lastSeen = l.now()
+}
@@ -0,0 +1,76 @@
+package detector
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/lldp"
+)
+
+var _ = Describe("LLDP", func() {
+	var (
+		l   *LLDP
+		now time.Time
+	)
+
+	neighbor := lldp.Neighbor{ChassisID: "02:00:00:00:00:01", PortID: "Ethernet1/1", SystemName: "switch1", TTL: 30 * time.Second}
+
+	BeforeEach(func() {
+		d, err := New("lldp", "test", nil, map[string]string{"chassisID": "02:00:00:00:00:01", "portID": "Ethernet1/1", "failOnLoss": "true"})
+		Expect(err).NotTo(HaveOccurred())
+		l = d.(*LLDP)
+		now = time.Now()
+		l.now = func() time.Time { return now }
+	})
+
+	It("should be healthy until a neighbor is seen", func() {
+		Expect(l.Detect(&netlink.Dummy{})).To(Equal(Status{Healthy: true}))
+	})
+
+	It("should publish the expected neighbor", func() {
+		l.update(neighbor)
+		Expect(l.Detect(&netlink.Dummy{})).To(Equal(Status{Healthy: true, Attributes: neighbor.Attributes()}))
+	})
+
+	It("should fail when the neighbor changes", func() {
+		changed := neighbor
+		changed.PortID = "Ethernet1/2"
+		l.update(changed)
+
+		s, err := l.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Healthy).To(BeFalse())
+		Expect(s.Reason).To(Equal(`unexpected lldp neighbor: port id is "Ethernet1/2", expected "Ethernet1/1"`))
+		Expect(s.Attributes).To(HaveKeyWithValue("port_id", "Ethernet1/2"))
+	})
+
+	It("should fail when the neighbor is lost", func() {
+		l.update(neighbor)
+		now = now.Add(31 * time.Second)
+
+		s, err := l.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Healthy).To(BeFalse())
+		Expect(s.Reason).To(Equal("lldp neighbor 02:00:00:00:00:01 lost"))
+		Expect(s.Attributes).To(HaveKeyWithValue("lost", "true"))
+
+		By("ignoring the loss when not configured to fail")
+		l.failOnLoss = false
+		Expect(l.Detect(&netlink.Dummy{})).To(HaveField("Healthy", BeTrue()))
+	})
+
+	It("should prefix the attributes in a chain", func() {
+		l.update(neighbor)
+		s, err := Chain{l, Carrier{}}.Detect(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUp}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Attributes).To(HaveKeyWithValue("lldp.chassis_id", "02:00:00:00:00:01"))
+	})
+
+	It("should reject an invalid failOnLoss", func() {
+		_, err := New("lldp", "test", nil, map[string]string{"failOnLoss": "yes"})
+		Expect(err).To(MatchError(`invalid lldp failOnLoss "yes"`))
+	})
+})
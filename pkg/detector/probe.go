@@ -0,0 +1,187 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+	"github.com/openshift/pf-status-relay/pkg/log"
+	"github.com/openshift/pf-status-relay/pkg/probe"
+)
+
+func init() {
+	Register("probe", func(pf string, nl interfaces.Netlink, options map[string]string) (Detector, error) {
+		method := options["method"]
+		if method == "" {
+			method = "arp"
+		}
+		prober, err := probe.New(method)
+		if err != nil {
+			return nil, err
+		}
+
+		var targets []net.IP
+		for _, t := range strings.Split(options["targets"], ",") {
+			if t = strings.TrimSpace(t); t == "" {
+				continue
+			}
+			ip := net.ParseIP(t)
+			if ip == nil || ip.To4() == nil {
+				return nil, fmt.Errorf("invalid probe target %q", t)
+			}
+			targets = append(targets, ip)
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("probe detector requires the targets option")
+		}
+
+		p := NewProbe(prober, targets)
+		p.iface = bondOf(pf, nl)
+		if iface := options["interface"]; iface != "" {
+			p.iface = func() (string, error) {
+				return iface, nil
+			}
+		}
+
+		for key, value := range map[string]*time.Duration{"interval": &p.interval, "timeout": &p.timeout} {
+			if raw, ok := options[key]; ok {
+				ms, err := strconv.Atoi(raw)
+				if err != nil || ms <= 0 {
+					return nil, fmt.Errorf("invalid probe %s %q", key, raw)
+				}
+				*value = time.Duration(ms) * time.Millisecond
+			}
+		}
+
+		if raw, ok := options["failures"]; ok {
+			p.threshold, err = strconv.Atoi(raw)
+			if err != nil || p.threshold <= 0 {
+				return nil, fmt.Errorf("invalid probe failures %q", raw)
+			}
+		}
+
+		return p, nil
+	})
+}
+
+// Probe reports a PF as down when targets do not answer to a number of consecutive rounds of probes.
+type Probe struct {
+	prober    probe.Prober
+	targets   []net.IP
+	iface     func() (string, error)
+	interval  time.Duration
+	timeout   time.Duration
+	threshold int
+
+	mu       sync.Mutex
+	failures int
+	lastErr  error
+}
+
+// NewProbe returns a Probe detector that sends probes to targets. A round of probes succeeds when any target answers.
+func NewProbe(prober probe.Prober, targets []net.IP) *Probe {
+	return &Probe{
+		prober:    prober,
+		targets:   targets,
+		interval:  time.Second,
+		timeout:   500 * time.Millisecond,
+		threshold: 3,
+	}
+}
+
+// bondOf returns a function that resolves the name of the bond of the PF.
+func bondOf(pf string, nl interfaces.Netlink) func() (string, error) {
+	return func() (string, error) {
+		link, err := nl.LinkByName(pf)
+		if err != nil {
+			return "", err
+		}
+		if link.Attrs().MasterIndex == 0 {
+			return "", fmt.Errorf("interface %s has no master interface", pf)
+		}
+
+		bond, err := nl.LinkByIndex(link.Attrs().MasterIndex)
+		if err != nil {
+			return "", err
+		}
+
+		return bond.Attrs().Name, nil
+	}
+}
+
+// Name returns the name of the detector.
+func (p *Probe) Name() string {
+	return "probe"
+}
+
+// ValueNames returns the names of the values published by the detector.
+func (p *Probe) ValueNames() []string {
+	return []string{"failures"}
+}
+
+// Detect returns the result of the last rounds of probes.
+func (p *Probe) Detect(netlink.Link) (Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The reason is kept stable while the PF stays down, the failures and the last error are reported apart.
+	status := Status{Healthy: true, Values: map[string]float64{"failures": float64(p.failures)}}
+	if p.lastErr != nil {
+		status.Attributes = map[string]string{"lastError": p.lastErr.Error()}
+	}
+	if p.failures >= p.threshold {
+		status.Healthy, status.Reason = false, "consecutive probe failures"
+	}
+
+	return status, nil
+}
+
+// Run sends a round of probes every interval until ctx is cancelled.
+func (p *Probe) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.round()
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Log.Debug("ctx cancelled", "routine", "probe")
+			return
+		}
+	}
+}
+
+// round probes every target until one of them answers.
+func (p *Probe) round() {
+	iface, err := p.iface()
+	if err == nil {
+		for _, target := range p.targets {
+			err = p.prober.Probe(iface, target, p.timeout)
+			if err == nil {
+				break
+			}
+			err = fmt.Errorf("%s via %s: %w", target, iface, err)
+			log.Log.Debug("probe failed", "error", err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		p.failures = 0
+		p.lastErr = nil
+		return
+	}
+	p.failures++
+	p.lastErr = err
+}
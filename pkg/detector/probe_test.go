@@ -0,0 +1,137 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+)
+
+type fakeProber struct {
+	mu        sync.Mutex
+	reachable map[string]bool
+	probes    []string
+}
+
+func (f *fakeProber) Probe(iface string, target net.IP, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.probes = append(f.probes, iface+"/"+target.String())
+	if f.reachable[target.String()] {
+		return nil
+	}
+
+	return errors.New("timeout")
+}
+
+var _ = Describe("Probe", func() {
+	var (
+		prober *fakeProber
+		p      *Probe
+	)
+
+	BeforeEach(func() {
+		prober = &fakeProber{reachable: map[string]bool{}}
+		p = NewProbe(prober, []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")})
+		p.iface = func() (string, error) {
+			return "bond0", nil
+		}
+	})
+
+	It("should be healthy while a target answers", func() {
+		prober.reachable["10.0.0.2"] = true
+		p.round()
+
+		s, err := p.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Healthy).To(BeTrue())
+		Expect(prober.probes).To(Equal([]string{"bond0/10.0.0.1", "bond0/10.0.0.2"}))
+	})
+
+	It("should be down after the configured consecutive failures", func() {
+		for range 2 {
+			p.round()
+		}
+
+		s, err := p.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Healthy).To(BeTrue())
+		Expect(s.Values).To(HaveKeyWithValue("failures", 2.0))
+
+		p.round()
+
+		s, err = p.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Healthy).To(BeFalse())
+		Expect(s.Reason).To(Equal("consecutive probe failures"))
+		Expect(s.Values).To(HaveKeyWithValue("failures", 3.0))
+		Expect(s.Attributes).To(HaveKeyWithValue("lastError", "10.0.0.2 via bond0: timeout"))
+
+		By("recovering when a target answers")
+		prober.reachable["10.0.0.1"] = true
+		p.round()
+
+		s, err = p.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Healthy).To(BeTrue())
+		Expect(s.Attributes).To(BeEmpty())
+	})
+
+	It("should count a failure when the interface cannot be resolved", func() {
+		p.threshold = 1
+		p.iface = func() (string, error) {
+			return "", errors.New("interface test has no master interface")
+		}
+		p.round()
+
+		s, err := p.Detect(&netlink.Dummy{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.Reason).To(Equal("consecutive probe failures"))
+		Expect(s.Attributes).To(HaveKeyWithValue("lastError", "interface test has no master interface"))
+		Expect(prober.probes).To(BeEmpty())
+	})
+
+	It("should probe in the background until cancelled", func() {
+		p.interval = 10 * time.Millisecond
+		p.threshold = 2
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			Run(ctx, Chain{p})
+		}()
+
+		Eventually(func() bool {
+			s, _ := p.Detect(&netlink.Dummy{})
+			return s.Healthy
+		}, "1s", "10ms").Should(BeFalse())
+
+		cancel()
+		Eventually(done, "1s").Should(BeClosed())
+	})
+
+	It("should validate options", func() {
+		_, err := New("probe", "test", nil, map[string]string{})
+		Expect(err).To(MatchError("probe detector requires the targets option"))
+
+		_, err = New("probe", "test", nil, map[string]string{"targets": "gateway"})
+		Expect(err).To(MatchError(`invalid probe target "gateway"`))
+
+		_, err = New("probe", "test", nil, map[string]string{"targets": "10.0.0.1", "method": "bfd"})
+		Expect(err).To(MatchError(`unknown probe method "bfd"`))
+
+		_, err = New("probe", "test", nil, map[string]string{"targets": "10.0.0.1", "failures": "0"})
+		Expect(err).To(MatchError(`invalid probe failures "0"`))
+
+		d, err := New("probe", "test", nil, map[string]string{"targets": "10.0.0.1", "method": "icmp", "interval": "200"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.(*Probe).interval).To(Equal(200 * time.Millisecond))
+	})
+})
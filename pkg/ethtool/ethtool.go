@@ -0,0 +1,97 @@
+package ethtool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ethSSStats is the string set of the NIC specific statistics.
+	ethSSStats = 1
+	// ethGStringLen is the length of the name of a statistic.
+	ethGStringLen = 32
+)
+
+// ifreq is the request used by SIOCETHTOOL.
+type ifreq struct {
+	name [unix.IFNAMSIZ]byte
+	data unsafe.Pointer
+	_    [16]byte
+}
+
+// Stats returns the NIC specific statistics of the interface, as reported by "ethtool -S".
+func Stats(name string) (map[string]uint64, error) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	// Fetch the number of statistics.
+	sset := make([]byte, 20)
+	binary.NativeEndian.PutUint32(sset[0:], unix.ETHTOOL_GSSET_INFO)
+	binary.NativeEndian.PutUint64(sset[8:], 1<<ethSSStats)
+	err = ioctl(fd, name, sset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get number of statistics of %s: %w", name, err)
+	}
+	n := binary.NativeEndian.Uint32(sset[16:])
+	if n == 0 {
+		return map[string]uint64{}, nil
+	}
+
+	// Fetch the names of the statistics.
+	names := make([]byte, 12+n*ethGStringLen)
+	binary.NativeEndian.PutUint32(names[0:], unix.ETHTOOL_GSTRINGS)
+	binary.NativeEndian.PutUint32(names[4:], ethSSStats)
+	binary.NativeEndian.PutUint32(names[8:], n)
+	err = ioctl(fd, name, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statistic names of %s: %w", name, err)
+	}
+
+	// Fetch the values of the statistics.
+	values := make([]byte, 8+n*8)
+	binary.NativeEndian.PutUint32(values[0:], unix.ETHTOOL_GSTATS)
+	binary.NativeEndian.PutUint32(values[4:], n)
+	err = ioctl(fd, name, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statistics of %s: %w", name, err)
+	}
+
+	// The number of statistics may have changed between requests, use the smallest one.
+	n = min(n, binary.NativeEndian.Uint32(names[8:]), binary.NativeEndian.Uint32(values[4:]))
+	stats := make(map[string]uint64, n)
+	for i := range n {
+		raw := names[12+i*ethGStringLen : 12+(i+1)*ethGStringLen]
+		if end := bytes.IndexByte(raw, 0); end >= 0 {
+			raw = raw[:end]
+		}
+		stats[string(raw)] = binary.NativeEndian.Uint64(values[8+i*8:])
+	}
+
+	return stats, nil
+}
+
+// ioctl sends an ethtool command stored in data to the interface.
+func ioctl(fd int, name string, data []byte) error {
+	if len(name) >= unix.IFNAMSIZ {
+		return fmt.Errorf("interface name %s is too long", name)
+	}
+
+	req := ifreq{data: unsafe.Pointer(&data[0])}
+	copy(req.name[:], name)
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.SIOCETHTOOL, uintptr(unsafe.Pointer(&req)))
+	runtime.KeepAlive(data)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat tells whether a routine is alive: the routine beats regularly, and is stalled when it did not beat
+// for longer than the timeout.
+type Heartbeat struct {
+	name    string
+	timeout time.Duration
+	last    atomic.Int64
+}
+
+// NewHeartbeat returns the heartbeat of the routine with the given name, which beats for the first time.
+func NewHeartbeat(name string, timeout time.Duration) *Heartbeat {
+	h := &Heartbeat{name: name, timeout: timeout}
+	h.Beat()
+
+	return h
+}
+
+// Beat records that the routine is alive. It does nothing on a nil heartbeat.
+func (h *Heartbeat) Beat() {
+	if h == nil {
+		return
+	}
+
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Interval returns the interval at which routines that wait for events must beat while they wait.
+func (h *Heartbeat) Interval() time.Duration {
+	if h == nil {
+		return time.Hour
+	}
+
+	return h.timeout / 3
+}
+
+// Check returns whether the routine is alive.
+func (h *Heartbeat) Check() Check {
+	last := time.Unix(0, h.last.Load())
+	c := Check{Name: h.name, OK: time.Since(last) <= h.timeout}
+	if !c.OK {
+		c.Reason = "no heartbeat since " + last.UTC().Format(time.RFC3339)
+	}
+
+	return c
+}
+
+// Check is the result of the check of a component.
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Result is the result of the checks of all components.
+type Result struct {
+	OK     bool    `json:"ok"`
+	Checks []Check `json:"checks"`
+}
+
+// Liveness returns the checks of the given heartbeats.
+func Liveness(heartbeats ...*Heartbeat) func() []Check {
+	return func() []Check {
+		checks := make([]Check, 0, len(heartbeats))
+		for _, h := range heartbeats {
+			checks = append(checks, h.Check())
+		}
+
+		return checks
+	}
+}
+
+// Handler returns an HTTP handler that reports the result of checks as JSON. It answers 503 when any check fails.
+func Handler(checks func() []Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		r := Result{OK: true, Checks: checks()}
+		for _, c := range r.Checks {
+			r.OK = r.OK && c.OK
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !r.OK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(r)
+	})
+}
@@ -0,0 +1,70 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health", func() {
+	Context("Heartbeat", func() {
+		It("should be stalled when it did not beat before the timeout", func() {
+			h := NewHeartbeat("monitor", 50*time.Millisecond)
+			Expect(h.Check()).To(Equal(Check{Name: "monitor", OK: true}))
+
+			Eventually(func() bool { return h.Check().OK }).Should(BeFalse())
+			Expect(h.Check().Reason).To(HavePrefix("no heartbeat since "))
+
+			h.Beat()
+			Expect(h.Check().OK).To(BeTrue())
+		})
+
+		It("should do nothing when nil", func() {
+			var h *Heartbeat
+			h.Beat()
+			Expect(h.Interval()).To(Equal(time.Hour))
+		})
+	})
+
+	Context("Handler", func() {
+		It("should report the checks as JSON", func() {
+			checks := []Check{{Name: "eth0", OK: true}}
+			handler := Handler(func() []Check { return checks })
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+			var r Result
+			Expect(json.Unmarshal(rec.Body.Bytes(), &r)).To(Succeed())
+			Expect(r).To(Equal(Result{OK: true, Checks: checks}))
+
+			checks = append(checks, Check{Name: "eth1", Reason: "link is not up"})
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rec.Body.String()).To(Equal(`{"ok":false,"checks":[{"name":"eth0","ok":true},{"name":"eth1","ok":false,"reason":"link is not up"}]}` + "\n"))
+		})
+
+		It("should fail the liveness of a stalled heartbeat", func() {
+			alive := NewHeartbeat("monitor", time.Hour)
+			stalled := NewHeartbeat("subscribe", time.Nanosecond)
+			time.Sleep(time.Millisecond)
+
+			rec := httptest.NewRecorder()
+			Handler(Liveness(alive, stalled)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+
+			var r Result
+			Expect(json.Unmarshal(rec.Body.Bytes(), &r)).To(Succeed())
+			Expect(r.Checks).To(HaveLen(2))
+			Expect(r.Checks[0].OK).To(BeTrue())
+			Expect(r.Checks[1].OK).To(BeFalse())
+		})
+	})
+})
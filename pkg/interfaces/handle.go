@@ -0,0 +1,70 @@
+package interfaces
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// rtextFilterSkipStats asks the kernel to omit the statistics of the VFs of a link.
+const rtextFilterSkipStats = 1 << 3
+
+// Handle is the Netlink implementation backed by the kernel. It requests links without the statistics of their
+// VFs, which are not used and make up most of the VF info of PFs with many VFs.
+type Handle struct {
+	netlink.Handle
+}
+
+// LinkByIndex returns the link with the given index along with the info of its VFs, without their statistics.
+func (h *Handle) LinkByIndex(index int) (netlink.Link, error) {
+	return getLink(index, nl.RTEXT_FILTER_VF|rtextFilterSkipStats)
+}
+
+// LinkStatusByIndex returns the link with the given index without the info of its VFs.
+func (h *Handle) LinkStatusByIndex(index int) (netlink.Link, error) {
+	return getLink(index, rtextFilterSkipStats)
+}
+
+// LinkStatusList returns all the links without the info of their VFs, in a single dump.
+func (h *Handle) LinkStatusList() ([]netlink.Link, error) {
+	req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_DUMP)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(unix.IFLA_EXT_MASK, nl.Uint32Attr(rtextFilterSkipStats)))
+
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]netlink.Link, 0, len(msgs))
+	for _, m := range msgs {
+		link, err := netlink.LinkDeserialize(nil, m)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// getLink requests the link with the given index with an extended info mask.
+func getLink(index int, mask uint32) (netlink.Link, error) {
+	req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_ACK)
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(index)
+	req.AddData(msg)
+	req.AddData(nl.NewRtAttr(unix.IFLA_EXT_MASK, nl.Uint32Attr(mask)))
+
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("expected one link with index %d, got %d", index, len(msgs))
+	}
+
+	return netlink.LinkDeserialize(nil, msgs[0])
+}
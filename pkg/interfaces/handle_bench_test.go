@@ -0,0 +1,33 @@
+package interfaces
+
+import "testing"
+
+// benchmarkPFs is the number of PFs polled per cycle.
+const benchmarkPFs = 8
+
+// BenchmarkPoll compares the netlink round-trips of requesting the status of every PF of a node one by one, with a
+// single dump of all links. The loopback interface stands for the PFs; the cost of polling PFs with VFs is measured
+// by BenchmarkPoll of the lacp package.
+func BenchmarkPoll(b *testing.B) {
+	h := &Handle{}
+
+	b.Run("byIndex", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			for range benchmarkPFs {
+				if _, err := h.LinkStatusByIndex(1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("dump", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := h.LinkStatusList(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
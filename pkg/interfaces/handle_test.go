@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handle", func() {
+	h := &Handle{}
+
+	It("should get a link", func() {
+		link, err := h.LinkByIndex(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link.Attrs().Name).To(Equal("lo"))
+		Expect(link.Attrs().Vfs).To(BeEmpty())
+
+		link, err = h.LinkStatusByIndex(1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link.Attrs().Name).To(Equal("lo"))
+		Expect(link.Attrs().Vfs).To(BeEmpty())
+	})
+
+	It("should list the links", func() {
+		links, err := h.LinkStatusList()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(links).To(ContainElement(HaveField("Attrs().Name", "lo")))
+	})
+
+	It("should return an error for a missing link", func() {
+		_, err := h.LinkStatusByIndex(1 << 30)
+		Expect(err).To(HaveOccurred())
+	})
+})
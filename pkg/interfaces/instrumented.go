@@ -0,0 +1,99 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/metrics"
+)
+
+// Instrumented is a Netlink that records the duration and the errors of the requests of another Netlink.
+type Instrumented struct {
+	nl Netlink
+}
+
+// Instrument returns nl recording the duration and the errors of its requests in the metrics.
+func Instrument(nl Netlink) *Instrumented {
+	return &Instrumented{nl: nl}
+}
+
+// observe records a request of method that started at start and returned err.
+func observe(method string, start time.Time, err error) {
+	metrics.NetlinkDuration.Observe(time.Since(start).Seconds(), method)
+	if err != nil {
+		metrics.NetlinkErrors.Inc(method)
+	}
+}
+
+func (i *Instrumented) LinkByIndex(index int) (netlink.Link, error) {
+	start := time.Now()
+	link, err := i.nl.LinkByIndex(index)
+	observe("LinkByIndex", start, err)
+
+	return link, err
+}
+
+func (i *Instrumented) LinkStatusByIndex(index int) (netlink.Link, error) {
+	start := time.Now()
+	link, err := i.nl.LinkStatusByIndex(index)
+	observe("LinkStatusByIndex", start, err)
+
+	return link, err
+}
+
+func (i *Instrumented) LinkStatusList() ([]netlink.Link, error) {
+	start := time.Now()
+	links, err := i.nl.LinkStatusList()
+	observe("LinkStatusList", start, err)
+
+	return links, err
+}
+
+func (i *Instrumented) LinkByName(name string) (netlink.Link, error) {
+	start := time.Now()
+	link, err := i.nl.LinkByName(name)
+	observe("LinkByName", start, err)
+
+	return link, err
+}
+
+func (i *Instrumented) LinkSetVfState(link netlink.Link, vf int, state uint32) error {
+	start := time.Now()
+	err := i.nl.LinkSetVfState(link, vf, state)
+	observe("LinkSetVfState", start, err)
+
+	return err
+}
+
+func (i *Instrumented) LinkSetUp(link netlink.Link) error {
+	start := time.Now()
+	err := i.nl.LinkSetUp(link)
+	observe("LinkSetUp", start, err)
+
+	return err
+}
+
+func (i *Instrumented) LinkSetDown(link netlink.Link) error {
+	start := time.Now()
+	err := i.nl.LinkSetDown(link)
+	observe("LinkSetDown", start, err)
+
+	return err
+}
+
+func (i *Instrumented) DevLinkGetAllPortList() ([]*netlink.DevlinkPort, error) {
+	start := time.Now()
+	ports, err := i.nl.DevLinkGetAllPortList()
+	observe("DevLinkGetAllPortList", start, err)
+
+	return ports, err
+}
+
+func (i *Instrumented) DevlinkPortFnSet(bus, device string, index uint32, attrs netlink.DevlinkPortFnSetAttrs) error {
+	start := time.Now()
+	err := i.nl.DevlinkPortFnSet(bus, device, index, attrs)
+	observe("DevlinkPortFnSet", start, err)
+
+	return err
+}
@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/pf-status-relay/pkg/metrics"
+)
+
+var _ = Describe("Instrumented", func() {
+	It("should record the duration and the errors of requests", func() {
+		mockNetlink := NewMockNetlink(gomock.NewController(GinkgoT()))
+		i := Instrument(mockNetlink)
+
+		link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "pf0"}}
+		mockNetlink.EXPECT().LinkStatusByIndex(1).Return(link, nil).Times(1)
+		mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_DISABLE)).Return(errors.New("busy")).Times(1)
+
+		requests := metrics.NetlinkDuration.Count("LinkStatusByIndex")
+		errs := metrics.NetlinkErrors.Value("LinkSetVfState")
+
+		Expect(i.LinkStatusByIndex(1)).To(BeIdenticalTo(link))
+		Expect(i.LinkSetVfState(link, 0, netlink.VF_LINK_STATE_DISABLE)).To(MatchError("busy"))
+
+		Expect(metrics.NetlinkDuration.Count("LinkStatusByIndex")).To(Equal(requests + 1))
+		Expect(metrics.NetlinkErrors.Value("LinkStatusByIndex")).To(BeZero())
+		Expect(metrics.NetlinkErrors.Value("LinkSetVfState")).To(Equal(errs + 1))
+	})
+})
@@ -4,6 +4,14 @@ import "github.com/vishvananda/netlink"
 
 type Netlink interface {
 	LinkByIndex(int) (netlink.Link, error)
+	// LinkStatusByIndex returns the link with the given index without the info of its VFs.
+	LinkStatusByIndex(int) (netlink.Link, error)
+	// LinkStatusList returns all the links without the info of their VFs.
+	LinkStatusList() ([]netlink.Link, error)
 	LinkByName(string) (netlink.Link, error)
 	LinkSetVfState(netlink.Link, int, uint32) error
+	LinkSetUp(netlink.Link) error
+	LinkSetDown(netlink.Link) error
+	DevLinkGetAllPortList() ([]*netlink.DevlinkPort, error)
+	DevlinkPortFnSet(string, string, uint32, netlink.DevlinkPortFnSetAttrs) error
 }
@@ -20,6 +20,7 @@ import (
 type MockNetlink struct {
 	ctrl     *gomock.Controller
 	recorder *MockNetlinkMockRecorder
+	isgomock struct{}
 }
 
 // MockNetlinkMockRecorder is the mock recorder for MockNetlink.
@@ -39,6 +40,35 @@ func (m *MockNetlink) EXPECT() *MockNetlinkMockRecorder {
 	return m.recorder
 }
 
+// DevLinkGetAllPortList mocks base method.
+func (m *MockNetlink) DevLinkGetAllPortList() ([]*netlink.DevlinkPort, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevLinkGetAllPortList")
+	ret0, _ := ret[0].([]*netlink.DevlinkPort)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DevLinkGetAllPortList indicates an expected call of DevLinkGetAllPortList.
+func (mr *MockNetlinkMockRecorder) DevLinkGetAllPortList() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevLinkGetAllPortList", reflect.TypeOf((*MockNetlink)(nil).DevLinkGetAllPortList))
+}
+
+// DevlinkPortFnSet mocks base method.
+func (m *MockNetlink) DevlinkPortFnSet(arg0, arg1 string, arg2 uint32, arg3 netlink.DevlinkPortFnSetAttrs) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DevlinkPortFnSet", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DevlinkPortFnSet indicates an expected call of DevlinkPortFnSet.
+func (mr *MockNetlinkMockRecorder) DevlinkPortFnSet(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevlinkPortFnSet", reflect.TypeOf((*MockNetlink)(nil).DevlinkPortFnSet), arg0, arg1, arg2, arg3)
+}
+
 // LinkByIndex mocks base method.
 func (m *MockNetlink) LinkByIndex(arg0 int) (netlink.Link, error) {
 	m.ctrl.T.Helper()
@@ -69,6 +99,34 @@ func (mr *MockNetlinkMockRecorder) LinkByName(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkByName", reflect.TypeOf((*MockNetlink)(nil).LinkByName), arg0)
 }
 
+// LinkSetDown mocks base method.
+func (m *MockNetlink) LinkSetDown(arg0 netlink.Link) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetDown", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetDown indicates an expected call of LinkSetDown.
+func (mr *MockNetlinkMockRecorder) LinkSetDown(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetDown", reflect.TypeOf((*MockNetlink)(nil).LinkSetDown), arg0)
+}
+
+// LinkSetUp mocks base method.
+func (m *MockNetlink) LinkSetUp(arg0 netlink.Link) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkSetUp", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkSetUp indicates an expected call of LinkSetUp.
+func (mr *MockNetlinkMockRecorder) LinkSetUp(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetUp", reflect.TypeOf((*MockNetlink)(nil).LinkSetUp), arg0)
+}
+
 // LinkSetVfState mocks base method.
 func (m *MockNetlink) LinkSetVfState(arg0 netlink.Link, arg1 int, arg2 uint32) error {
 	m.ctrl.T.Helper()
@@ -82,3 +140,33 @@ func (mr *MockNetlinkMockRecorder) LinkSetVfState(arg0, arg1, arg2 any) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkSetVfState", reflect.TypeOf((*MockNetlink)(nil).LinkSetVfState), arg0, arg1, arg2)
 }
+
+// LinkStatusByIndex mocks base method.
+func (m *MockNetlink) LinkStatusByIndex(arg0 int) (netlink.Link, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkStatusByIndex", arg0)
+	ret0, _ := ret[0].(netlink.Link)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LinkStatusByIndex indicates an expected call of LinkStatusByIndex.
+func (mr *MockNetlinkMockRecorder) LinkStatusByIndex(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkStatusByIndex", reflect.TypeOf((*MockNetlink)(nil).LinkStatusByIndex), arg0)
+}
+
+// LinkStatusList mocks base method.
+func (m *MockNetlink) LinkStatusList() ([]netlink.Link, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkStatusList")
+	ret0, _ := ret[0].([]netlink.Link)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LinkStatusList indicates an expected call of LinkStatusList.
+func (mr *MockNetlinkMockRecorder) LinkStatusList() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkStatusList", reflect.TypeOf((*MockNetlink)(nil).LinkStatusList))
+}
@@ -0,0 +1,85 @@
+package interfaces
+
+import (
+	"sync"
+
+	"github.com/vishvananda/netlink"
+)
+
+// Snapshot is a Netlink that answers the lookups of links from the last dump of all links, so that the PFs and
+// their bonds cost a single request per refresh. Only the status of links is answered from the dump, along with
+// bonds which have no VFs; everything else, and the links that are not in the dump, is requested from Netlink.
+type Snapshot struct {
+	Netlink
+
+	mu      sync.RWMutex
+	byIndex map[int]netlink.Link
+	byName  map[string]netlink.Link
+}
+
+// NewSnapshot returns an empty Snapshot backed by nl.
+func NewSnapshot(nl Netlink) *Snapshot {
+	return &Snapshot{Netlink: nl}
+}
+
+// Refresh replaces the snapshot with a new dump of all links. The snapshot is emptied when the dump fails, so
+// that links are requested one by one until the next refresh.
+func (s *Snapshot) Refresh() error {
+	links, err := s.Netlink.LinkStatusList()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.byIndex, s.byName = nil, nil
+	if err != nil {
+		return err
+	}
+
+	s.byIndex = make(map[int]netlink.Link, len(links))
+	s.byName = make(map[string]netlink.Link, len(links))
+	for _, link := range links {
+		s.byIndex[link.Attrs().Index] = link
+		s.byName[link.Attrs().Name] = link
+	}
+
+	return nil
+}
+
+// LinkStatusByIndex returns the link with the given index from the snapshot.
+func (s *Snapshot) LinkStatusByIndex(index int) (netlink.Link, error) {
+	s.mu.RLock()
+	link, ok := s.byIndex[index]
+	s.mu.RUnlock()
+
+	if !ok {
+		return s.Netlink.LinkStatusByIndex(index)
+	}
+
+	return link, nil
+}
+
+// LinkByIndex returns the link with the given index from the snapshot when it is a bond.
+func (s *Snapshot) LinkByIndex(index int) (netlink.Link, error) {
+	s.mu.RLock()
+	link, ok := s.byIndex[index]
+	s.mu.RUnlock()
+
+	if _, bond := link.(*netlink.Bond); !ok || !bond {
+		return s.Netlink.LinkByIndex(index)
+	}
+
+	return link, nil
+}
+
+// LinkByName returns the link with the given name from the snapshot when it is a bond.
+func (s *Snapshot) LinkByName(name string) (netlink.Link, error) {
+	s.mu.RLock()
+	link, ok := s.byName[name]
+	s.mu.RUnlock()
+
+	if _, bond := link.(*netlink.Bond); !ok || !bond {
+		return s.Netlink.LinkByName(name)
+	}
+
+	return link, nil
+}
@@ -0,0 +1,59 @@
+package interfaces
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Snapshot", func() {
+	var (
+		mockNetlink *MockNetlink
+		snapshot    *Snapshot
+		pf          = &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "pf0", MasterIndex: 2}}
+		bond        = &netlink.Bond{LinkAttrs: netlink.LinkAttrs{Index: 2, Name: "bond0"}, Mode: netlink.BOND_MODE_802_3AD}
+	)
+
+	BeforeEach(func() {
+		mockNetlink = NewMockNetlink(gomock.NewController(GinkgoT()))
+		snapshot = NewSnapshot(mockNetlink)
+	})
+
+	It("should answer the status of links and bonds from the dump", func() {
+		mockNetlink.EXPECT().LinkStatusList().Return([]netlink.Link{pf, bond}, nil).Times(1)
+		Expect(snapshot.Refresh()).To(Succeed())
+
+		Expect(snapshot.LinkStatusByIndex(1)).To(BeIdenticalTo(pf))
+		Expect(snapshot.LinkByIndex(2)).To(BeIdenticalTo(bond))
+		Expect(snapshot.LinkByName("bond0")).To(BeIdenticalTo(bond))
+	})
+
+	It("should request the links that need the info of their VFs", func() {
+		mockNetlink.EXPECT().LinkStatusList().Return([]netlink.Link{pf, bond}, nil).Times(1)
+		Expect(snapshot.Refresh()).To(Succeed())
+
+		full := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "pf0", Vfs: []netlink.VfInfo{{ID: 0}}}}
+		mockNetlink.EXPECT().LinkByIndex(1).Return(full, nil).Times(1)
+		mockNetlink.EXPECT().LinkByName("pf0").Return(full, nil).Times(1)
+		Expect(snapshot.LinkByIndex(1)).To(BeIdenticalTo(full))
+		Expect(snapshot.LinkByName("pf0")).To(BeIdenticalTo(full))
+	})
+
+	It("should request the links that are not in the dump", func() {
+		mockNetlink.EXPECT().LinkStatusList().Return([]netlink.Link{pf, bond}, nil).Times(1)
+		mockNetlink.EXPECT().LinkStatusList().Return(nil, errors.New("dump interrupted")).Times(1)
+		Expect(snapshot.Refresh()).To(Succeed())
+
+		other := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 3, Name: "pf1"}}
+		mockNetlink.EXPECT().LinkStatusByIndex(3).Return(other, nil).Times(1)
+		Expect(snapshot.LinkStatusByIndex(3)).To(BeIdenticalTo(other))
+
+		By("emptying the snapshot when the dump fails")
+		Expect(snapshot.Refresh()).To(MatchError("dump interrupted"))
+		mockNetlink.EXPECT().LinkStatusByIndex(1).Return(pf, nil).Times(1)
+		Expect(snapshot.LinkStatusByIndex(1)).To(BeIdenticalTo(pf))
+	})
+})
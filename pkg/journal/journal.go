@@ -0,0 +1,194 @@
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Version is the version of the journal format.
+const Version = 1
+
+// ErrCorrupt is returned when the journal cannot be decoded or its checksum does not match.
+var ErrCorrupt = errors.New("journal is corrupt")
+
+// Owned maps the id of the VFs owned by an actuator to their original link state.
+type Owned map[int]uint32
+
+// file is the on-disk format of the journal. The checksum is the SHA-256 of Data.
+type file struct {
+	Version  int             `json:"version"`
+	Checksum string          `json:"checksum"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Journal persists the VFs owned by the actuators of every PF, so that they can be restored after a restart.
+type Journal struct {
+	path string
+
+	mu sync.Mutex
+	// pfs maps PF names to actuator names to their owned VFs.
+	pfs map[string]map[string]Owned
+}
+
+// Open reads the journal at path. A missing file results in an empty journal. When the journal is corrupt, it
+// is moved aside and an empty journal is returned along with an error wrapping ErrCorrupt.
+func Open(path string) (*Journal, error) {
+	j := &Journal{path: path, pfs: make(map[string]map[string]Owned)}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	err = decode(raw, &j.pfs)
+	if err != nil {
+		j.pfs = make(map[string]map[string]Owned)
+		if rerr := os.Rename(path, path+".corrupt"); rerr != nil {
+			return j, errors.Join(err, rerr)
+		}
+		return j, err
+	}
+
+	return j, nil
+}
+
+func decode(raw []byte, pfs *map[string]map[string]Owned) error {
+	var f file
+	err := json.Unmarshal(raw, &f)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	if f.Version != Version {
+		return fmt.Errorf("%w: unsupported version %d", ErrCorrupt, f.Version)
+	}
+
+	sum := sha256.Sum256(f.Data)
+	if hex.EncodeToString(sum[:]) != f.Checksum {
+		return fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+
+	err = json.Unmarshal(f.Data, pfs)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	return nil
+}
+
+// Owned returns the VFs owned by an actuator of a PF.
+func (j *Journal) Owned(pf, actuator string) Owned {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return maps.Clone(j.pfs[pf][actuator])
+}
+
+// Update records the VFs owned by an actuator of a PF. The journal is written only when it changed.
+func (j *Journal) Update(pf, actuator string, owned Owned) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if maps.Equal(j.pfs[pf][actuator], owned) {
+		return nil
+	}
+
+	if len(owned) == 0 {
+		delete(j.pfs[pf], actuator)
+		if len(j.pfs[pf]) == 0 {
+			delete(j.pfs, pf)
+		}
+	} else {
+		if j.pfs[pf] == nil {
+			j.pfs[pf] = make(map[string]Owned)
+		}
+		j.pfs[pf][actuator] = maps.Clone(owned)
+	}
+
+	return j.write()
+}
+
+// Prune removes the PFs that are not listed in pfs.
+func (j *Journal) Prune(pfs []string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	keep := make(map[string]bool, len(pfs))
+	for _, pf := range pfs {
+		keep[pf] = true
+	}
+
+	changed := false
+	for pf := range j.pfs {
+		if !keep[pf] {
+			delete(j.pfs, pf)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	return j.write()
+}
+
+// write replaces the journal atomically: the content is written to a temporary file in the same directory,
+// synced and renamed over the journal.
+func (j *Journal) write() error {
+	data, err := json.Marshal(j.pfs)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	raw, err := json.Marshal(file{Version: Version, Checksum: hex.EncodeToString(sum[:]), Data: data})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(j.path)
+	err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(j.path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(append(raw, '\n'))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), j.path)
+	if err != nil {
+		return fmt.Errorf("failed to replace journal: %w", err)
+	}
+
+	// Sync the directory so that the rename survives a crash.
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to sync journal directory: %w", err)
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
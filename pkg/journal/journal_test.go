@@ -0,0 +1,73 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Journal", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "run", "state.json")
+	})
+
+	It("should start empty when the file does not exist", func() {
+		j, err := Open(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(j.Owned("eth0", "vfstate")).To(BeEmpty())
+	})
+
+	It("should persist the owned VFs", func() {
+		j, err := Open(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(j.Update("eth0", "vfstate", Owned{0: 0, 3: 1})).To(Succeed())
+		Expect(j.Update("eth1", "vfstate", Owned{1: 0})).To(Succeed())
+
+		j, err = Open(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(j.Owned("eth0", "vfstate")).To(Equal(Owned{0: 0, 3: 1}))
+		Expect(j.Owned("eth1", "vfstate")).To(Equal(Owned{1: 0}))
+
+		By("removing the PFs without owned VFs")
+		Expect(j.Update("eth0", "vfstate", nil)).To(Succeed())
+		Expect(j.Prune([]string{"eth0"})).To(Succeed())
+
+		raw, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(ContainSubstring(`"version":1`))
+		Expect(string(raw)).To(ContainSubstring(`"data":{}`))
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	DescribeTable("should detect corruption",
+		func(modify func(string) string, message string) {
+			j, err := Open(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(j.Update("eth0", "vfstate", Owned{0: 0})).To(Succeed())
+
+			raw, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(path, []byte(modify(string(raw))), 0o600)).To(Succeed())
+
+			j, err = Open(path)
+			Expect(err).To(MatchError(ErrCorrupt))
+			Expect(err).To(MatchError(ContainSubstring(message)))
+			Expect(j.Owned("eth0", "vfstate")).To(BeEmpty())
+
+			By("moving the corrupt journal aside")
+			Expect(path).NotTo(BeAnExistingFile())
+			Expect(path + ".corrupt").To(BeAnExistingFile())
+		},
+		Entry("truncated file", func(s string) string { return s[:len(s)/2] }, "unexpected end of JSON input"),
+		Entry("unsupported version", func(s string) string { return strings.Replace(s, `"version":1`, `"version":2`, 1) }, "unsupported version 2"),
+		Entry("modified data", func(s string) string { return strings.Replace(s, `"0":0`, `"0":1`, 1) }, "checksum mismatch"),
+	)
+})
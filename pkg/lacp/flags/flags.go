@@ -16,6 +16,21 @@ const (
 	Expired
 )
 
+// Names are the names of the LACP flags by bit.
+var Names = []string{"activity", "timeout", "aggregation", "synchronization", "collecting", "distributing", "defaulted", "expired"}
+
+// Decode returns the names of the LACP flags set in a port state.
+func Decode(state uint16) []string {
+	names := make([]string, 0, len(Names))
+	for bit, name := range Names {
+		if state>>bit&1 != 0 {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
 type flags uint8
 
 // isOperational inspects lacp flags to determine if protocol is up.
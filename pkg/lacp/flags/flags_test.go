@@ -7,6 +7,13 @@ import (
 )
 
 var _ = Describe("Flags", func() {
+	Describe("Decode", func() {
+		It("should return the names of the flags that are set", func() {
+			Expect(Decode(Activity | Aggregation | Synchronization | Collecting | Distributing)).To(Equal([]string{"activity", "aggregation", "synchronization", "collecting", "distributing"}))
+			Expect(Decode(0)).To(BeEmpty())
+		})
+	})
+
 	Describe("isOperational", func() {
 		It("should return true when flags are Distributing, Collecting, Synchronization, and Aggregation", func() {
 			f := flags(Distributing | Collecting | Synchronization | Aggregation)
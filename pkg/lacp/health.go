@@ -0,0 +1,43 @@
+package lacp
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/openshift/pf-status-relay/pkg/health"
+)
+
+// Heartbeat returns the heartbeat of the monitor loop.
+func (i *Nics) Heartbeat() *health.Heartbeat {
+	return i.heartbeat
+}
+
+// Readiness returns a check for every configured PF, which passes when the PF was found and passed its inspection.
+func (i *Nics) Readiness() []health.Check {
+	byName := make(map[string]bool, len(i.PFs))
+	checks := make([]health.Check, 0, len(i.interfaces))
+	for _, p := range i.PFs {
+		name := p.State().Name
+		ready, reason := p.Readiness()
+		if !ready && reason == "" {
+			reason = "pf was not inspected yet"
+		}
+		byName[name] = true
+		checks = append(checks, health.Check{Name: name, OK: ready, Reason: reason})
+	}
+
+	for _, name := range i.interfaces {
+		if byName[name] {
+			continue
+		}
+
+		reason, ok := i.missing[name]
+		if !ok {
+			reason = "interface was not found"
+		}
+		checks = append(checks, health.Check{Name: name, Reason: reason})
+	}
+	slices.SortFunc(checks, func(a, b health.Check) int { return cmp.Compare(a.Name, b.Name) })
+
+	return checks
+}
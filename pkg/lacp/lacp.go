@@ -1,42 +1,118 @@
 package lacp
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/vishvananda/netlink"
 
+	"github.com/openshift/pf-status-relay/pkg/actuator"
+	"github.com/openshift/pf-status-relay/pkg/config"
+	"github.com/openshift/pf-status-relay/pkg/detector"
+	"github.com/openshift/pf-status-relay/pkg/health"
 	"github.com/openshift/pf-status-relay/pkg/interfaces"
-	"github.com/openshift/pf-status-relay/pkg/lacp/flags"
+	"github.com/openshift/pf-status-relay/pkg/journal"
 	"github.com/openshift/pf-status-relay/pkg/lacp/pf"
 	"github.com/openshift/pf-status-relay/pkg/log"
+	"github.com/openshift/pf-status-relay/pkg/metrics"
 )
 
 // Nics stores the PFs that are inspected.
 type Nics struct {
-	PFs             map[int]*pf.PF
+	PFs map[int]*pf.PF
+	// interfaces are the names of the configured PFs.
+	interfaces []string
+	// missing maps the configured PFs that were not added to PFs to the reason they were not.
+	missing         map[string]string
 	queue           <-chan int
 	pollingInterval int
+	// minPollingInterval is the polling interval right after a PF changed.
+	minPollingInterval int
+	// wake is signaled on link events to poll at the min polling interval.
+	wake            chan struct{}
+	holdDown        time.Duration
+	shutdownPolicy  config.ShutdownPolicy
+	shutdownTimeout time.Duration
+	retry           actuator.Retry
+	batch           actuator.Batch
 	nl              interfaces.Netlink
+	// snapshot is refreshed with a dump of all links once per polling interval when set. It backs nl.
+	snapshot *interfaces.Snapshot
+	// sysfs is the mount point of sysfs, where the number of VFs of the PFs is read from.
+	sysfs   string
+	journal *journal.Journal
+	// heartbeat beats on every iteration of the monitor loop, and whenever actuators make progress while the health
+	// of PFs is relayed.
+	heartbeat *health.Heartbeat
 }
 
 // New returns an Nics structure with interfaces that are found in the node.
-func New(nics []string, queue <-chan int, pollingInterval int, nl interfaces.Netlink) Nics {
+func New(conf config.Config, queue <-chan int, nl interfaces.Netlink) Nics {
 	i := Nics{
-		PFs:             make(map[int]*pf.PF),
-		queue:           queue,
-		pollingInterval: pollingInterval,
-		nl:              nl,
+		PFs:                make(map[int]*pf.PF),
+		interfaces:         conf.Interfaces,
+		missing:            make(map[string]string),
+		queue:              queue,
+		pollingInterval:    conf.PollingInterval,
+		minPollingInterval: conf.MinPollingInterval,
+		wake:               make(chan struct{}, 1),
+		holdDown:           time.Duration(conf.HoldDown) * time.Millisecond,
+		shutdownPolicy:     conf.ShutdownPolicy,
+		shutdownTimeout:    time.Duration(conf.ShutdownTimeout) * time.Millisecond,
+		retry: actuator.Retry{
+			Attempts:   conf.ActuationAttempts,
+			Backoff:    time.Duration(conf.ActuationBackoff) * time.Millisecond,
+			MaxBackoff: time.Duration(conf.ActuationMaxBackoff) * time.Millisecond,
+			Verify:     conf.ActuationVerify,
+		},
+		batch:     actuator.Batch{Concurrency: conf.ActuationConcurrency, Rate: conf.ActuationRate},
+		nl:        nl,
+		sysfs:     "/sys",
+		heartbeat: health.NewHeartbeat("monitor", time.Duration(conf.StallTimeout)*time.Millisecond),
 	}
-	for _, name := range nics {
+
+	if conf.LinkDump {
+		i.snapshot = interfaces.NewSnapshot(nl)
+		nl, i.nl = i.snapshot, i.snapshot
+	}
+
+	if conf.StateFile != "" {
+		j, err := journal.Open(conf.StateFile)
+		switch {
+		case j == nil:
+			log.Log.Warn("failed to open state journal, the VFs disabled by this instance will not be restored after a restart", "path", conf.StateFile, "error", err)
+		case err != nil:
+			log.Log.Error("failed to read state journal", "path", conf.StateFile, "error", err)
+		}
+		i.journal = j
+	}
+
+	for _, name := range conf.Interfaces {
 		link, err := i.nl.LinkByName(name)
 		if err != nil {
 			log.Log.Warn("failed to fetch interface", "interface", name, "error", err)
+			i.missing[name] = fmt.Sprintf("failed to fetch interface: %s", err)
+			continue
+		}
+
+		d, a, err := build(name, conf.PF(name), nl)
+		if err != nil {
+			log.Log.Error("failed to configure interface", "interface", name, "error", err)
+			i.missing[name] = fmt.Sprintf("failed to configure interface: %s", err)
 			continue
 		}
 
-		log.Log.Debug("adding interface", "interface", name)
+		log.Log.Debug("adding interface", "interface", name, "detector", d.Name(), "actuator", a.Name())
+		i.configure(a)
+		i.restore(name, link, a)
 
 		i.PFs[link.Attrs().Index] = &pf.PF{
 			Name:        link.Attrs().Name,
@@ -45,26 +121,155 @@ func New(nics []string, queue <-chan int, pollingInterval int, nl interfaces.Net
 			MasterIndex: link.Attrs().MasterIndex,
 
 			ProtoState: pf.Undefined,
+			Detector:   d,
+			Actuator:   a,
 			Nl:         nl,
 		}
+		i.PFs[link.Attrs().Index].Publish()
+	}
+
+	if i.journal != nil {
+		err := i.journal.Prune(conf.Interfaces)
+		if err != nil {
+			log.Log.Error("failed to write state journal", "error", err)
+		}
 	}
 
 	return i
 }
 
+// configure sets how the actuators of a PF set the state of VFs. Their progress beats the heartbeat, as actuation
+// can take longer than the stall timeout when it is retried or rate limited.
+func (i *Nics) configure(a actuator.Actuator) {
+	for _, a := range actuator.Unwrap(a) {
+		if r, ok := a.(actuator.Retrier); ok {
+			r.SetRetry(i.retry)
+		}
+		if b, ok := a.(actuator.Batcher); ok {
+			b.SetBatch(i.batch)
+		}
+		if r, ok := a.(actuator.ProgressReporter); ok {
+			r.SetProgress(i.heartbeat.Beat)
+		}
+	}
+}
+
+// restore gives the actuators of a PF the ownership of the VFs recorded in the journal.
+func (i *Nics) restore(name string, link netlink.Link, a actuator.Actuator) {
+	if i.journal == nil {
+		return
+	}
+
+	for _, a := range actuator.Unwrap(a) {
+		s, ok := a.(actuator.Stateful)
+		if !ok {
+			continue
+		}
+
+		owned := i.journal.Owned(name, a.Name())
+		if len(owned) > 0 {
+			log.Log.Info("restoring vf ownership", "interface", name, "actuator", a.Name(), "vfs", len(owned))
+			s.Restore(link, owned)
+		}
+	}
+	i.persist(name, a)
+}
+
+// persist records the VFs owned by the actuators of a PF in the journal.
+func (i *Nics) persist(name string, a actuator.Actuator) {
+	if i.journal == nil {
+		return
+	}
+
+	for _, a := range actuator.Unwrap(a) {
+		s, ok := a.(actuator.Stateful)
+		if !ok {
+			continue
+		}
+
+		err := i.journal.Update(name, a.Name(), s.Owned())
+		if err != nil {
+			log.Log.Error("failed to write state journal", "interface", name, "error", err)
+		}
+	}
+}
+
+// build creates the detector and the actuator of a PF from its configuration.
+func build(name string, conf config.PFConfig, nl interfaces.Netlink) (detector.Detector, actuator.Actuator, error) {
+	detectors := make(detector.Chain, 0, len(conf.Detectors))
+	ids := make(map[string]detector.Detector, len(conf.Detectors))
+	for _, c := range conf.Detectors {
+		d, err := detector.New(c.Name, name, nl, c.Options)
+		if err != nil {
+			return nil, nil, err
+		}
+		detectors = append(detectors, d)
+		ids[c.Identifier()] = d
+	}
+
+	actuators := make(actuator.Chain, 0, len(conf.Actuators))
+	for _, c := range conf.Actuators {
+		a, err := actuator.New(c.Name, name, nl, c.Options)
+		if err != nil {
+			return nil, nil, err
+		}
+		actuators = append(actuators, a)
+	}
+
+	var d detector.Detector = detectors
+	switch {
+	case conf.Health != "":
+		e, err := detector.NewExpression(conf.Health, ids)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid health expression: %w", err)
+		}
+		d = e
+	case len(detectors) == 1:
+		d = detectors[0]
+	}
+
+	priority, err := actuator.NewPriority(name, conf.Priority)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, a := range actuator.Unwrap(actuators) {
+		if p, ok := a.(actuator.Prioritizer); ok {
+			p.SetPriority(priority)
+		}
+	}
+
+	var a actuator.Actuator = actuators
+	if len(actuators) == 1 {
+		a = actuators[0]
+	}
+
+	if len(conf.VFs.Include) > 0 || len(conf.VFs.Exclude) > 0 {
+		s, err := actuator.NewSelector(name, conf.VFs)
+		if err != nil {
+			return nil, nil, err
+		}
+		a = actuator.Select(a, s)
+	}
+
+	return d, a, nil
+}
+
 // Inspect inspects interfaces in order to proceed with monitoring.
 func (i *Nics) Inspect(ctx context.Context, wg *sync.WaitGroup) {
-	log.Log.Debug("LACP inspection and processing started")
+	log.Inspect.Debug("LACP inspection and processing started")
 
 	// Verify that PFs are ready to accept/receive LACPDU messages.
 	for _, p := range i.PFs {
 		err := p.Inspect()
+		p.Lock()
 		if err != nil {
-			log.Log.Error("pf is not ready", "interface", p.Name, "error", err)
-			continue
+			log.Inspect.Error("pf is not ready", "interface", p.Name, "error", err)
+			p.NotReadyReason = err.Error()
+		} else {
+			log.Inspect.Info("pf is ready", "interface", p.Name)
+			p.Ready = true
 		}
-		log.Log.Info("pf is ready", "interface", p.Name)
-		p.Ready = true
+		p.Unlock()
 	}
 
 	// Process link changes.
@@ -74,11 +279,15 @@ func (i *Nics) Inspect(ctx context.Context, wg *sync.WaitGroup) {
 		for {
 			select {
 			case index := <-i.queue:
-				log.Log.Debug("processing event", "index", index)
+				log.Inspect.Debug("processing event", "index", index)
+				select {
+				case i.wake <- struct{}{}:
+				default:
+				}
 				p := i.PFs[index]
 				updated, err := p.Update()
 				if err != nil {
-					log.Log.Error("failed to update link", "interface", p.Name, "error", err)
+					log.Inspect.Error("failed to update link", "interface", p.Name, "error", err)
 					break
 				}
 
@@ -86,18 +295,20 @@ func (i *Nics) Inspect(ctx context.Context, wg *sync.WaitGroup) {
 					err = p.Inspect()
 					if err != nil {
 						p.Lock()
-						log.Log.Error("pf is not ready", "interface", p.Name, "error", err)
+						log.Inspect.Error("pf is not ready", "interface", p.Name, "error", err)
 						p.Ready = false
+						p.NotReadyReason = err.Error()
 						p.Unlock()
 					} else {
 						p.Lock()
-						log.Log.Info("pf is ready", "interface", p.Name)
+						log.Inspect.Info("pf is ready", "interface", p.Name)
 						p.Ready = true
+						p.NotReadyReason = ""
 						p.Unlock()
 					}
 				}
 			case <-ctx.Done():
-				log.Log.Debug("ctx cancelled", "routine", "inspect")
+				log.Inspect.Debug("ctx cancelled", "routine", "inspect")
 				return
 			}
 		}
@@ -106,7 +317,18 @@ func (i *Nics) Inspect(ctx context.Context, wg *sync.WaitGroup) {
 
 // Monitor monitors the LACP protocol on the interfaces.
 func (i *Nics) Monitor(ctx context.Context, wg *sync.WaitGroup) {
-	log.Log.Debug("LACP monitoring started")
+	log.Monitor.Debug("LACP monitoring started")
+
+	// Start detectors that collect pf health in the background.
+	for _, p := range i.PFs {
+		if r, ok := p.Detector.(detector.Runner); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.Run(ctx)
+			}()
+		}
+	}
 
 	wg.Add(1)
 	go func() {
@@ -114,109 +336,285 @@ func (i *Nics) Monitor(ctx context.Context, wg *sync.WaitGroup) {
 			wg.Done()
 		}()
 
-		ticker := time.NewTicker(time.Duration(i.pollingInterval) * time.Millisecond)
-		defer ticker.Stop()
+		interval := time.Duration(i.pollingInterval) * time.Millisecond
+		deadline := time.Now().Add(interval)
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
 
 		for {
 			select {
-			case <-ticker.C:
-				var monitorWg sync.WaitGroup
-				for _, p := range i.PFs {
-					monitorWg.Add(1)
-					go func(p *pf.PF) {
-						defer monitorWg.Done()
-
-						p.Lock()
-						if !p.Ready {
-							p.Unlock()
-							return
-						}
-						p.Unlock()
-
-						link, err := i.nl.LinkByIndex(p.Index)
-						if err != nil {
-							log.Log.Warn("failed to fetch interface", "interface", p.Name, "error", err)
-							return
-						}
-
-						// Stop if interface has no VFs.
-						vfs := link.Attrs().Vfs
-						if len(vfs) == 0 {
-							if p.ProtoState != pf.NoVfs {
-								log.Log.Info("pf has no VFs", "interface", p.Name)
-								p.ProtoState = pf.NoVfs
-							}
-							return
-						}
-
-						// Log when VFs are detected after NoVfs state.
-						if p.ProtoState == pf.NoVfs {
-							log.Log.Info("VFs detected on interface", "interface", p.Name, "count", len(vfs))
-						}
-
-						// Check lacp state.
-						slave := link.Attrs().Slave
-						if slave != nil {
-							s, ok := slave.(*netlink.BondSlave)
-							if !ok {
-								log.Log.Error("interface does not have BondSlave type on Slave attribute", "interface", p.Name)
-								return
-							}
-
-							if flags.IsProtocolUp(s) {
-								if p.ProtoState != pf.Up {
-									log.Log.Info("lacp is up", "interface", p.Name)
-									p.ProtoState = pf.Up
-
-									if !flags.IsFastRate(s) {
-										log.Log.Warn("pf is using slow lacp rate", "interface", p.Name)
-									}
-								}
-
-								// Bring to auto all VFs whose state is disable.
-								for _, vf := range vfs {
-									log.Log.Debug("vf info", "id", vf.ID, "state", vf.LinkState, "interface", p.Name)
-									if vf.LinkState == netlink.VF_LINK_STATE_DISABLE {
-										err = i.nl.LinkSetVfState(link, vf.ID, netlink.VF_LINK_STATE_AUTO)
-										if err != nil {
-											log.Log.Error("failed to set vf link state", "id", vf.ID, "interface", p.Name, "error", err)
-										}
-										log.Log.Info("vf link state was set", "id", vf.ID, "state", "auto", "interface", p.Name)
-									}
-								}
-							} else {
-								if p.ProtoState != pf.Down {
-									log.Log.Info("lacp is down", "interface", p.Name)
-									p.ProtoState = pf.Down
-								}
-
-								// Bring to disable all VFs whose state is auto.
-								for _, vf := range vfs {
-									log.Log.Debug("vf info", "id", vf.ID, "state", vf.LinkState, "interface", p.Name)
-									if vf.LinkState == netlink.VF_LINK_STATE_AUTO {
-										err = i.nl.LinkSetVfState(link, vf.ID, netlink.VF_LINK_STATE_DISABLE)
-										if err != nil {
-											log.Log.Error("failed to set vf link state", "id", vf.ID, "interface", p.Name, "error", err)
-										}
-										log.Log.Info("vf link state was set", "id", vf.ID, "state", "disable", "interface", p.Name)
-									}
-								}
-							}
-						} else {
-							log.Log.Error("interface has no slave attribute", "interface", p.Name)
-						}
-					}(p)
-
-					monitorWg.Wait()
+			case <-timer.C:
+				interval = i.next(interval, i.poll())
+				deadline = time.Now().Add(interval)
+				timer.Reset(interval)
+				i.heartbeat.Beat()
+			case <-i.wake:
+				// Link events only bring the next poll forward, so that a stream of events cannot delay it.
+				interval = i.next(interval, true)
+				if time.Until(deadline) > interval {
+					deadline = time.Now().Add(interval)
+					timer.Reset(interval)
 				}
 			case <-ctx.Done():
-				log.Log.Debug("ctx cancelled", "routine", "monitor")
+				log.Monitor.Debug("ctx cancelled", "routine", "monitor")
 				return
 			}
 		}
 	}()
 }
 
+// next returns the polling interval that follows interval: the min polling interval when a PF changed, and twice
+// interval otherwise, up to the polling interval. Polling is not adaptive when the min polling interval is not set.
+func (i *Nics) next(interval time.Duration, changed bool) time.Duration {
+	minimum := time.Duration(cmp.Or(i.minPollingInterval, i.pollingInterval)) * time.Millisecond
+	maximum := time.Duration(i.pollingInterval) * time.Millisecond
+	if changed {
+		return minimum
+	}
+
+	return min(max(2*interval, minimum), maximum)
+}
+
+// poll monitors every PF once, after refreshing the snapshot of the links when enabled. It returns true when any
+// PF changed.
+func (i *Nics) poll() bool {
+	if i.snapshot != nil {
+		err := i.snapshot.Refresh()
+		if err != nil {
+			log.Monitor.Warn("failed to dump links", "error", err)
+		}
+	}
+
+	// PFs are monitored in parallel, so that a slow actuation does not delay the others.
+	var changed atomic.Bool
+	var monitorWg sync.WaitGroup
+	for _, p := range i.PFs {
+		monitorWg.Add(1)
+		go func(p *pf.PF) {
+			defer monitorWg.Done()
+			if i.monitor(p) {
+				changed.Store(true)
+			}
+			p.Publish()
+		}(p)
+	}
+	monitorWg.Wait()
+
+	return changed.Load()
+}
+
+// monitor checks the health of a PF and relays it to its VFs. It returns true when the PF is changing: its health
+// or its LACP port states changed, or it is held down.
+func (i *Nics) monitor(p *pf.PF) bool {
+	p.Lock()
+	if !p.Ready {
+		p.Unlock()
+		return false
+	}
+	p.Unlock()
+
+	link, vfs, err := i.fetch(p)
+	if err != nil {
+		log.Monitor.Warn("failed to fetch interface", "interface", p.Name, "error", err)
+		return false
+	}
+
+	// Stop if interface has no VFs.
+	if vfs == 0 {
+		if p.ProtoState != pf.NoVfs {
+			transition(p, pf.NoVfs, "")
+			log.Monitor.Info("pf has no VFs", "interface", p.Name, "lacp_state", p.ProtoState.String())
+		}
+		return false
+	}
+
+	// Log when VFs are detected after NoVfs state.
+	if p.ProtoState == pf.NoVfs {
+		log.Monitor.Info("VFs detected on interface", "interface", p.Name, "count", vfs)
+	}
+
+	// Check pf health.
+	status, err := p.Detector.Detect(link)
+	if err != nil {
+		log.Monitor.Error("failed to detect pf health", "interface", p.Name, "detector", p.Detector.Name(), "error", err)
+		return false
+	}
+
+	changed := portStateChanged(p, link)
+	changed = changed || status.Healthy != p.Health.Healthy || status.Reason != p.Health.Reason
+
+	// Keep VFs down during hold-down after the PF recovered.
+	healthy := status.Healthy
+	if !healthy {
+		p.HoldDownUntil = time.Time{}
+	} else if p.ProtoState == pf.Down && i.holdDown > 0 {
+		now := time.Now()
+		if p.HoldDownUntil.IsZero() {
+			p.HoldDownUntil = now.Add(i.holdDown)
+			log.Monitor.Info("pf is healthy, holding down", "interface", p.Name, "until", p.HoldDownUntil)
+		}
+		healthy = !now.Before(p.HoldDownUntil)
+	}
+
+	previous := p.ProtoState
+	switch {
+	case healthy:
+		if p.ProtoState != pf.Up {
+			transition(p, pf.Up, "")
+			log.Monitor.Info("lacp is up", "interface", p.Name, "lacp_state", p.ProtoState.String())
+			p.HoldDownUntil = time.Time{}
+
+			for _, w := range status.Warnings {
+				log.Monitor.Warn(w, "interface", p.Name)
+			}
+		}
+	case !status.Healthy:
+		if p.ProtoState != pf.Down {
+			transition(p, pf.Down, status.Reason)
+			log.Monitor.Info("lacp is down", "interface", p.Name, "lacp_state", p.ProtoState.String(), "reason", status.Reason)
+		}
+	}
+	p.Health = status
+	changed = changed || !p.HoldDownUntil.IsZero()
+
+	// Relay pf health to VFs, fetching their info only when they may change.
+	start := time.Now()
+	if healthy && idle(p.Actuator) {
+		setActuationError(p, nil)
+		return changed
+	}
+	if len(link.Attrs().Vfs) != vfs {
+		link, err = i.nl.LinkByIndex(p.Index)
+		if err != nil {
+			log.Monitor.Warn("failed to fetch interface", "interface", p.Name, "error", err)
+			return changed
+		}
+	}
+	err = p.Actuator.Apply(link, healthy)
+	if err != nil {
+		log.Monitor.Error("failed to relay pf health", "interface", p.Name, "actuator", p.Actuator.Name(), "error", err)
+		metrics.ActuationFailures.Inc(p.Name)
+	}
+	// Report the time to relay a failure, or a recovery from it, to all VFs.
+	if p.ProtoState != previous && (p.ProtoState == pf.Down || previous == pf.Down) {
+		p.ActuationDuration = time.Since(start)
+		metrics.ActuationDuration.Observe(p.ActuationDuration.Seconds(), p.Name)
+		log.Monitor.Info("pf health was relayed", "interface", p.Name, "healthy", healthy, "vfs", vfs, "duration", p.ActuationDuration)
+	}
+	setActuationError(p, err)
+	i.persist(p.Name, p.Actuator)
+
+	return changed
+}
+
+// fetch returns the link of a PF along with its number of VFs. The info of the VFs is only fetched when their
+// number cannot be read from sysfs.
+func (i *Nics) fetch(p *pf.PF) (netlink.Link, int, error) {
+	raw, err := os.ReadFile(filepath.Join(i.sysfs, "class", "net", p.Name, "device", "sriov_numvfs"))
+	if err != nil {
+		link, err := i.nl.LinkByIndex(p.Index)
+		if err != nil {
+			return nil, 0, err
+		}
+		return link, len(link.Attrs().Vfs), nil
+	}
+
+	vfs, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid number of vfs: %w", err)
+	}
+
+	link, err := i.nl.LinkStatusByIndex(p.Index)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return link, vfs, nil
+}
+
+// transition sets the LACP state of a PF, recording the time and the reason of the transition. Transitions are
+// counted by detector, since reasons are free-form.
+func transition(p *pf.PF, state pf.ProtoState, reason string) {
+	p.ProtoState = state
+	p.TransitionTime, p.TransitionReason = time.Now(), reason
+	metrics.Transitions.Inc(p.Name, state.String(), p.Detector.Name())
+}
+
+// portStateChanged records the LACP port states of a PF from link, and returns true when they changed.
+func portStateChanged(p *pf.PF, link netlink.Link) bool {
+	slave, ok := link.Attrs().Slave.(*netlink.BondSlave)
+	if !ok {
+		return false
+	}
+
+	changed := slave.AdActorOperPortState != p.ActorPortState || slave.AdPartnerOperPortState != p.PartnerPortState
+	p.ActorPortState, p.PartnerPortState = slave.AdActorOperPortState, slave.AdPartnerOperPortState
+
+	return changed
+}
+
+// idle returns true when relaying a healthy PF through a would not change any VF.
+func idle(a actuator.Actuator) bool {
+	for _, a := range actuator.Unwrap(a) {
+		if i, ok := a.(actuator.Idler); !ok || !i.Idle() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setActuationError raises the actuation failed condition of a PF when err is set, and clears it otherwise.
+func setActuationError(p *pf.PF, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	switch {
+	case err != nil && p.ActuationError == nil:
+		log.Monitor.Error("actuation failed", "interface", p.Name, "actuator", p.Actuator.Name())
+		p.ActuationFailedSince = time.Now()
+	case err == nil && p.ActuationError != nil:
+		log.Monitor.Info("actuation recovered", "interface", p.Name, "actuator", p.Actuator.Name(), "since", p.ActuationFailedSince)
+		p.ActuationFailedSince = time.Time{}
+	}
+	p.ActuationError = err
+}
+
+// Shutdown applies the shutdown policy to the VFs owned by the actuators of every PF, giving up after the
+// shutdown timeout. It must be called after Monitor stopped.
+func (i *Nics) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), i.shutdownTimeout)
+	defer cancel()
+
+	for _, p := range i.PFs {
+		for _, a := range actuator.Unwrap(p.Actuator) {
+			s, ok := a.(actuator.Stateful)
+			if !ok || len(s.Owned()) == 0 {
+				continue
+			}
+
+			if i.shutdownPolicy == config.ShutdownLeave || (i.shutdownPolicy == config.ShutdownRestoreIfUp && !p.Health.Healthy) {
+				for id := range s.Owned() {
+					log.Log.Info("vf is left disabled", "id", id, "interface", p.Name, "policy", i.shutdownPolicy)
+				}
+				continue
+			}
+
+			link, err := i.nl.LinkByIndex(p.Index)
+			if err != nil {
+				log.Log.Error("failed to fetch interface", "interface", p.Name, "error", err)
+				continue
+			}
+
+			err = s.Release(ctx, link)
+			if err != nil {
+				log.Log.Error("failed to restore vfs", "interface", p.Name, "actuator", a.Name(), "error", err)
+			}
+		}
+		i.persist(p.Name, p.Actuator)
+	}
+}
+
 // Indexes returns a list of indexes.
 func (i *Nics) Indexes() []int {
 	indexes := make([]int, 0, len(i.PFs))
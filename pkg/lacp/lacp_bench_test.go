@@ -0,0 +1,244 @@
+package lacp
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/sys/unix"
+
+	"github.com/openshift/pf-status-relay/pkg/actuator"
+	"github.com/openshift/pf-status-relay/pkg/detector"
+	"github.com/openshift/pf-status-relay/pkg/interfaces"
+	"github.com/openshift/pf-status-relay/pkg/lacp/pf"
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+// benchmarkVfs is the number of VFs of the benchmarked PF.
+const benchmarkVfs = 127
+
+// benchmarkPFs is the number of PFs polled per cycle.
+const benchmarkPFs = 8
+
+// linkMessage returns the RTM_NEWLINK message of an up bond slave with LACP up, as sent by the kernel. The info
+// of vfs VFs is included, along with their statistics if stats is set.
+func linkMessage(index int, name string, vfs int, stats bool) []byte {
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(index)
+
+	attrs := []*nl.RtAttr{
+		nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(name)),
+		nl.NewRtAttr(unix.IFLA_OPERSTATE, nl.Uint8Attr(uint8(netlink.OperUp))),
+		nl.NewRtAttr(unix.IFLA_MASTER, nl.Uint32Attr(100)),
+		// The statistics of the link are always sent.
+		nl.NewRtAttr(unix.IFLA_STATS64, make([]byte, 24*8)),
+	}
+
+	info := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	info.AddRtAttr(nl.IFLA_INFO_SLAVE_KIND, nl.ZeroTerminated("bond"))
+	slave := info.AddRtAttr(nl.IFLA_INFO_SLAVE_DATA, nil)
+	slave.AddRtAttr(nl.IFLA_BOND_SLAVE_AD_ACTOR_OPER_PORT_STATE, nl.Uint8Attr(63))
+	slave.AddRtAttr(nl.IFLA_BOND_SLAVE_AD_PARTNER_OPER_PORT_STATE, nl.Uint16Attr(63))
+	attrs = append(attrs, info)
+
+	if vfs > 0 {
+		list := nl.NewRtAttr(unix.IFLA_VFINFO_LIST, nil)
+		for id := range uint32(vfs) {
+			vf := list.AddRtAttr(nl.IFLA_VF_INFO, nil)
+			mac := nl.VfMac{Vf: id, Mac: [32]byte{0x02, 0, 0, 0, byte(id >> 8), byte(id)}}
+			vf.AddRtAttr(nl.IFLA_VF_MAC, mac.Serialize())
+			vf.AddRtAttr(nl.IFLA_VF_VLAN, (&nl.VfVlan{Vf: id}).Serialize())
+			vf.AddRtAttr(nl.IFLA_VF_TX_RATE, (&nl.VfTxRate{Vf: id}).Serialize())
+			vf.AddRtAttr(nl.IFLA_VF_RATE, (&nl.VfRate{Vf: id}).Serialize())
+			vf.AddRtAttr(nl.IFLA_VF_SPOOFCHK, (&nl.VfSpoofchk{Vf: id, Setting: 1}).Serialize())
+			vf.AddRtAttr(nl.IFLA_VF_LINK_STATE, (&nl.VfLinkState{Vf: id}).Serialize())
+			vf.AddRtAttr(nl.IFLA_VF_RSS_QUERY_EN, (&nl.VfRssQueryEn{Vf: id}).Serialize())
+			vf.AddRtAttr(nl.IFLA_VF_TRUST, (&nl.VfTrust{Vf: id}).Serialize())
+			if stats {
+				s := vf.AddRtAttr(nl.IFLA_VF_STATS, nil)
+				for stat := range nl.IFLA_VF_STATS_MAX + 1 {
+					s.AddRtAttr(stat, nl.Uint64Attr(uint64(id)<<20))
+				}
+			}
+		}
+		attrs = append(attrs, list)
+	}
+
+	raw := msg.Serialize()
+	for _, a := range attrs {
+		raw = append(raw, a.Serialize()...)
+	}
+
+	return raw
+}
+
+// benchmarkMonitor measures the cost of monitoring a healthy PF whose link is deserialized from raw. The number of
+// VFs is read from sysfs when found there.
+func benchmarkMonitor(b *testing.B, raw []byte, sysfs string) {
+	originalLogger := log.Log
+	log.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	b.Cleanup(func() { log.Log = originalLogger })
+
+	ctrl := gomock.NewController(b)
+	mockNetlink := interfaces.NewMockNetlink(ctrl)
+	deserialize := func(int) (netlink.Link, error) {
+		return netlink.LinkDeserialize(nil, raw)
+	}
+	mockNetlink.EXPECT().LinkByIndex(1).DoAndReturn(deserialize).AnyTimes()
+	mockNetlink.EXPECT().LinkStatusByIndex(1).DoAndReturn(deserialize).AnyTimes()
+
+	p := &pf.PF{
+		Name:       "test",
+		Index:      1,
+		Ready:      true,
+		ProtoState: pf.Undefined,
+		Detector:   detector.LACP{},
+		Actuator:   actuator.NewVfState("test", mockNetlink),
+		Nl:         mockNetlink,
+	}
+	nics := &Nics{PFs: map[int]*pf.PF{1: p}, nl: mockNetlink, sysfs: sysfs}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		nics.monitor(p)
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(len(raw)), "msgbytes/op")
+
+	if p.ProtoState != pf.Up {
+		b.Fatalf("pf is not up: %v", p.Health)
+	}
+}
+
+// BenchmarkMonitor compares fetching the full VF info of a PF on every poll, as done when the number of VFs cannot
+// be read from sysfs, with fetching its status only.
+func BenchmarkMonitor(b *testing.B) {
+	b.Run("dump", func(b *testing.B) {
+		benchmarkMonitor(b, linkMessage(1, "test", benchmarkVfs, true), b.TempDir())
+	})
+
+	b.Run("status", func(b *testing.B) {
+		sysfs := b.TempDir()
+		writeNumVfs(b, sysfs, "test", benchmarkVfs)
+
+		benchmarkMonitor(b, linkMessage(1, "test", 0, false), sysfs)
+	})
+}
+
+// writeNumVfs writes the number of VFs of the PF with the given name to sysfs.
+func writeNumVfs(b *testing.B, sysfs, name string, vfs int) {
+	device := filepath.Join(sysfs, "class", "net", name, "device")
+	if err := os.MkdirAll(device, 0o755); err != nil {
+		b.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(device, "sriov_numvfs"), []byte(strconv.Itoa(vfs)), 0o644); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// benchmarkPoll measures the cost of polling benchmarkPFs healthy PFs with vfs VFs each. The full info of the
+// PFs is fetched one by one unless status is set, in which case only their status is fetched, from a single dump
+// of all links if dump is also set. Links are deserialized from the messages sent by the kernel.
+func benchmarkPoll(b *testing.B, vfs int, status, dump bool) {
+	originalLogger, originalMonitor := log.Log, log.Monitor
+	log.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	log.Monitor = log.Log
+	b.Cleanup(func() { log.Log, log.Monitor = originalLogger, originalMonitor })
+
+	ctrl := gomock.NewController(b)
+	mockNetlink := interfaces.NewMockNetlink(ctrl)
+	sysfs := b.TempDir()
+
+	var nlh interfaces.Netlink = mockNetlink
+	var snapshot *interfaces.Snapshot
+	if dump {
+		snapshot = interfaces.NewSnapshot(mockNetlink)
+		nlh = snapshot
+	}
+	nics := &Nics{PFs: make(map[int]*pf.PF, benchmarkPFs), nl: nlh, snapshot: snapshot, sysfs: sysfs}
+
+	var bytes int
+	dumped := make([][]byte, 0, benchmarkPFs)
+	for index := 1; index <= benchmarkPFs; index++ {
+		name := "test" + strconv.Itoa(index)
+		full, statusOnly := linkMessage(index, name, vfs, true), linkMessage(index, name, 0, false)
+		mockNetlink.EXPECT().LinkByIndex(index).DoAndReturn(func(int) (netlink.Link, error) {
+			return netlink.LinkDeserialize(nil, full)
+		}).AnyTimes()
+		mockNetlink.EXPECT().LinkStatusByIndex(index).DoAndReturn(func(int) (netlink.Link, error) {
+			return netlink.LinkDeserialize(nil, statusOnly)
+		}).AnyTimes()
+
+		switch {
+		case dump:
+			dumped = append(dumped, statusOnly)
+			bytes += len(statusOnly)
+		case status:
+			bytes += len(statusOnly)
+		default:
+			bytes += len(full)
+		}
+		if status {
+			writeNumVfs(b, sysfs, name, vfs)
+		}
+
+		nics.PFs[index] = &pf.PF{
+			Name:       name,
+			Index:      index,
+			Ready:      true,
+			ProtoState: pf.Undefined,
+			Detector:   detector.LACP{},
+			Actuator:   actuator.NewVfState(name, nlh),
+			Nl:         nlh,
+		}
+	}
+	mockNetlink.EXPECT().LinkStatusList().DoAndReturn(func() ([]netlink.Link, error) {
+		links := make([]netlink.Link, 0, len(dumped))
+		for _, raw := range dumped {
+			link, err := netlink.LinkDeserialize(nil, raw)
+			if err != nil {
+				return nil, err
+			}
+			links = append(links, link)
+		}
+		return links, nil
+	}).AnyTimes()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		nics.poll()
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(bytes), "msgbytes/op")
+
+	for _, p := range nics.PFs {
+		if p.ProtoState != pf.Up {
+			b.Fatalf("pf %s is not up: %v", p.Name, p.Health)
+		}
+	}
+}
+
+// BenchmarkPoll compares, on benchmarkPFs PFs with a growing number of VFs, fetching the full info of every PF,
+// fetching their status one by one, and reading their status from a single dump of all links.
+func BenchmarkPoll(b *testing.B) {
+	for _, vfs := range []int{8, 32, benchmarkVfs} {
+		b.Run(fmt.Sprintf("vfs=%d/full", vfs), func(b *testing.B) {
+			benchmarkPoll(b, vfs, false, false)
+		})
+		b.Run(fmt.Sprintf("vfs=%d/status", vfs), func(b *testing.B) {
+			benchmarkPoll(b, vfs, true, false)
+		})
+		b.Run(fmt.Sprintf("vfs=%d/snapshot", vfs), func(b *testing.B) {
+			benchmarkPoll(b, vfs, true, true)
+		})
+	}
+}
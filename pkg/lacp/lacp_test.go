@@ -3,8 +3,18 @@ package lacp
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
@@ -13,9 +23,15 @@ import (
 	"github.com/vishvananda/netlink"
 	"go.uber.org/mock/gomock"
 
+	"github.com/openshift/pf-status-relay/pkg/actuator"
+	"github.com/openshift/pf-status-relay/pkg/config"
+	"github.com/openshift/pf-status-relay/pkg/detector"
+	"github.com/openshift/pf-status-relay/pkg/health"
 	"github.com/openshift/pf-status-relay/pkg/interfaces"
+	"github.com/openshift/pf-status-relay/pkg/journal"
 	"github.com/openshift/pf-status-relay/pkg/lacp/pf"
 	"github.com/openshift/pf-status-relay/pkg/log"
+	"github.com/openshift/pf-status-relay/pkg/metrics"
 )
 
 var _ = Describe("LACP", func() {
@@ -59,6 +75,8 @@ var _ = Describe("LACP", func() {
 						MasterIndex: 2,
 						Ready:       true,
 						ProtoState:  pf.Undefined,
+						Detector:    detector.LACP{},
+						Actuator:    actuator.NewVfState("test", mockNetlink),
 						Nl:          mockNetlink,
 					},
 				},
@@ -121,9 +139,9 @@ var _ = Describe("LACP", func() {
 				cancel()
 				wg.Wait()
 
-				wantLogs := `{"level":"INFO","msg":"pf has no VFs","interface":"test"}
+				wantLogs := `{"level":"INFO","msg":"pf has no VFs","interface":"test","lacp_state":"novfs"}
 {"level":"INFO","msg":"VFs detected on interface","interface":"test","count":2}
-{"level":"INFO","msg":"lacp is up","interface":"test"}
+{"level":"INFO","msg":"lacp is up","interface":"test","lacp_state":"up"}
 {"level":"WARN","msg":"pf is using slow lacp rate","interface":"test"}
 `
 
@@ -134,6 +152,684 @@ var _ = Describe("LACP", func() {
 		})
 	})
 
+	Context("Monitor with hold-down", func() {
+		It("should keep VFs disabled until the PF stays healthy for the hold-down time", func() {
+			var (
+				mu      sync.Mutex
+				state   = uint32(netlink.VF_LINK_STATE_AUTO)
+				healthy atomic.Bool
+			)
+
+			mockNetlink.EXPECT().LinkByIndex(1).DoAndReturn(func(int) (netlink.Link, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				return &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{
+					Index: 1,
+					Name:  "test",
+					Vfs:   []netlink.VfInfo{{ID: 0, LinkState: state}},
+				}}, nil
+			}).AnyTimes()
+			mockNetlink.EXPECT().LinkSetVfState(gomock.Any(), 0, gomock.Any()).DoAndReturn(func(_ netlink.Link, _ int, s uint32) error {
+				mu.Lock()
+				defer mu.Unlock()
+				state = s
+				return nil
+			}).AnyTimes()
+			vfState := func() uint32 {
+				mu.Lock()
+				defer mu.Unlock()
+				return state
+			}
+
+			nics = &Nics{
+				PFs: map[int]*pf.PF{
+					1: {
+						Name:       "test",
+						Index:      1,
+						Ready:      true,
+						ProtoState: pf.Undefined,
+						Detector:   toggle{healthy: &healthy},
+						Actuator:   actuator.NewVfState("test", mockNetlink),
+						Nl:         mockNetlink,
+					},
+				},
+				nl:              mockNetlink,
+				pollingInterval: 100,
+				holdDown:        500 * time.Millisecond,
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			wg := &sync.WaitGroup{}
+			nics.Monitor(ctx, wg)
+
+			By("disabling VFs when the PF is not healthy")
+			Eventually(vfState, "1s", "50ms").Should(Equal(uint32(netlink.VF_LINK_STATE_DISABLE)))
+
+			By("holding VFs down after the PF recovered")
+			healthy.Store(true)
+			Consistently(vfState, "300ms", "50ms").Should(Equal(uint32(netlink.VF_LINK_STATE_DISABLE)))
+
+			By("bringing VFs back after the hold-down time")
+			Eventually(vfState, "1s", "50ms").Should(Equal(uint32(netlink.VF_LINK_STATE_AUTO)))
+
+			cancel()
+			wg.Wait()
+
+			Expect(logBuf.String()).To(ContainSubstring(`"msg":"pf is healthy, holding down"`))
+			Expect(strings.Count(logBuf.String(), `"msg":"pf health was relayed"`)).To(Equal(2))
+			Expect(nics.PFs[1].ActuationDuration).To(BeNumerically(">", 0))
+		})
+	})
+
+	Context("adaptive polling", func() {
+		It("should poll at the min polling interval after a change and slow down back", func() {
+			nics = &Nics{pollingInterval: 1000, minPollingInterval: 100}
+
+			interval := nics.next(time.Second, true)
+			Expect(interval).To(Equal(100 * time.Millisecond))
+			for _, expected := range []time.Duration{200, 400, 800, 1000, 1000} {
+				interval = nics.next(interval, false)
+				Expect(interval).To(Equal(expected * time.Millisecond))
+			}
+		})
+
+		It("should not be adaptive without a min polling interval", func() {
+			nics = &Nics{pollingInterval: 1000}
+			Expect(nics.next(time.Second, true)).To(Equal(time.Second))
+			Expect(nics.next(time.Second, false)).To(Equal(time.Second))
+		})
+
+		It("should keep polling while link events keep coming", func() {
+			nics = &Nics{PFs: map[int]*pf.PF{}, pollingInterval: 200, wake: make(chan struct{}, 1), snapshot: interfaces.NewSnapshot(mockNetlink)}
+			polled := make(chan struct{}, 1)
+			mockNetlink.EXPECT().LinkStatusList().DoAndReturn(func() ([]netlink.Link, error) {
+				select {
+				case polled <- struct{}{}:
+				default:
+				}
+				return nil, nil
+			}).MinTimes(1)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			var wg sync.WaitGroup
+			nics.Monitor(ctx, &wg)
+
+			ticker := time.NewTicker(10 * time.Millisecond)
+			defer ticker.Stop()
+			timeout := time.After(time.Second)
+		events:
+			for {
+				select {
+				case <-ticker.C:
+					select {
+					case nics.wake <- struct{}{}:
+					default:
+					}
+				case <-polled:
+					break events
+				case <-timeout:
+					Fail("no poll while link events keep coming")
+				}
+			}
+
+			cancel()
+			wg.Wait()
+		})
+
+		It("should report a PF as changing until it is stable", func() {
+			var healthy atomic.Bool
+			healthy.Store(true)
+			p := &pf.PF{Name: "test", Index: 1, Ready: true, ProtoState: pf.Undefined, Detector: toggle{healthy: &healthy}, Actuator: actuator.NewVfState("test", mockNetlink)}
+			nics = &Nics{PFs: map[int]*pf.PF{1: p}, nl: mockNetlink, sysfs: GinkgoT().TempDir(), holdDown: time.Hour}
+
+			slave := &netlink.BondSlave{AdActorOperPortState: 63, AdPartnerOperPortState: 63}
+			link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test", Slave: slave, Vfs: []netlink.VfInfo{{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO}}}}
+			mockNetlink.EXPECT().LinkByIndex(1).Return(link, nil).AnyTimes()
+			mockNetlink.EXPECT().LinkSetVfState(link, 0, gomock.Any()).Return(nil).AnyTimes()
+
+			By("changing when the PF is first seen")
+			Expect(nics.monitor(p)).To(BeTrue())
+			Expect(nics.monitor(p)).To(BeFalse())
+
+			By("changing when the LACP port states change without a change of health")
+			slave.AdPartnerOperPortState = 61
+			Expect(nics.monitor(p)).To(BeTrue())
+			Expect(nics.monitor(p)).To(BeFalse())
+
+			By("changing while the PF is held down")
+			healthy.Store(false)
+			Expect(nics.monitor(p)).To(BeTrue())
+			Expect(nics.monitor(p)).To(BeFalse())
+			healthy.Store(true)
+			Expect(nics.monitor(p)).To(BeTrue())
+			Expect(nics.monitor(p)).To(BeTrue())
+			Expect(p.HoldDownUntil).NotTo(BeZero())
+		})
+	})
+
+	Context("Register", func() {
+		It("should expose the state of the PFs and their VFs", func() {
+			var healthy atomic.Bool
+			p := &pf.PF{Name: "test", Index: 1, Ready: true, ProtoState: pf.Undefined, Detector: toggle{healthy: &healthy}, Actuator: actuator.NewVfState("test", mockNetlink)}
+			nics = &Nics{PFs: map[int]*pf.PF{1: p}, nl: mockNetlink, sysfs: GinkgoT().TempDir()}
+			r := metrics.NewRegistry()
+			nics.Register(r)
+
+			slave := &netlink.BondSlave{AdActorOperPortState: 63, AdPartnerOperPortState: 61}
+			link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test", Slave: slave, Vfs: []netlink.VfInfo{
+				{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO},
+				{ID: 1, LinkState: netlink.VF_LINK_STATE_AUTO},
+			}}}
+			mockNetlink.EXPECT().LinkByIndex(1).Return(link, nil).AnyTimes()
+			mockNetlink.EXPECT().LinkSetVfState(link, gomock.Any(), uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(2)
+
+			transitions := metrics.Transitions.Value("test", "down", "toggle")
+			nics.poll()
+			Expect(metrics.Transitions.Value("test", "down", "toggle")).To(Equal(transitions + 1))
+
+			// Polling a PF that stays down is not a transition.
+			for i := range link.Vfs {
+				link.Vfs[i].LinkState = netlink.VF_LINK_STATE_DISABLE
+			}
+			nics.poll()
+			Expect(metrics.Transitions.Value("test", "down", "toggle")).To(Equal(transitions + 1))
+			for i := range link.Vfs {
+				link.Vfs[i].LinkState = netlink.VF_LINK_STATE_AUTO
+			}
+
+			var b bytes.Buffer
+			r.Write(&b)
+			Expect(b.String()).To(ContainSubstring(`pf_status_relay_lacp_state{interface="test",state="down"} 1` + "\n"))
+			Expect(b.String()).To(ContainSubstring(`pf_status_relay_lacp_state{interface="test",state="up"} 0` + "\n"))
+			Expect(b.String()).To(ContainSubstring(`pf_status_relay_lacp_port_state{interface="test",side="partner",flag="timeout"} 0` + "\n"))
+			Expect(b.String()).To(ContainSubstring(`pf_status_relay_lacp_port_state{interface="test",side="actor",flag="distributing"} 1` + "\n"))
+			Expect(b.String()).To(ContainSubstring(`pf_status_relay_vfs{interface="test",state="auto"} 2` + "\n"))
+			Expect(b.String()).To(ContainSubstring(`pf_status_relay_actuation_failed{interface="test"} 0` + "\n"))
+			Expect(b.String()).To(ContainSubstring(`pf_status_relay_ready{interface="test"} 1` + "\n"))
+		})
+	})
+
+	Context("Status", func() {
+		It("should describe the PFs and their VFs", func() {
+			var healthy atomic.Bool
+			p := &pf.PF{Name: "test", Index: 1, MasterIndex: 10, Ready: true, ProtoState: pf.Undefined, Detector: toggle{healthy: &healthy}, Actuator: actuator.NewVfState("test", mockNetlink)}
+			nics = &Nics{PFs: map[int]*pf.PF{1: p}, nl: mockNetlink, sysfs: GinkgoT().TempDir()}
+
+			slave := &netlink.BondSlave{AdActorOperPortState: 61, AdPartnerOperPortState: 13}
+			link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test", MasterIndex: 10, Slave: slave, Vfs: []netlink.VfInfo{
+				{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO},
+				{ID: 1, LinkState: netlink.VF_LINK_STATE_ENABLE},
+			}}}
+			bond := &netlink.Bond{LinkAttrs: netlink.LinkAttrs{Index: 10, Name: "bond0"}, Mode: netlink.BOND_MODE_802_3AD, AdInfo: &netlink.BondAdInfo{
+				AggregatorId: 2, NumPorts: 2, ActorKey: 15, PartnerKey: 9, PartnerMac: net.HardwareAddr{0, 1, 2, 3, 4, 5},
+			}}
+			mockNetlink.EXPECT().LinkByIndex(1).Return(link, nil).AnyTimes()
+			mockNetlink.EXPECT().LinkByIndex(10).Return(bond, nil).AnyTimes()
+			mockNetlink.EXPECT().LinkSetVfState(link, gomock.Any(), uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(2)
+
+			nics.poll()
+			link.Vfs[0].LinkState, link.Vfs[1].LinkState = netlink.VF_LINK_STATE_DISABLE, netlink.VF_LINK_STATE_DISABLE
+
+			rec := httptest.NewRecorder()
+			nics.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/test", nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			var s PFStatus
+			Expect(json.Unmarshal(rec.Body.Bytes(), &s)).To(Succeed())
+			Expect(s.Name).To(Equal("test"))
+			Expect(s.Ready).To(BeTrue())
+			Expect(s.Bond).To(Equal(&BondStatus{Name: "bond0", Index: 10, Mode: "802.3ad", AggregatorID: 2, NumPorts: 2, ActorKey: 15, PartnerKey: 9, PartnerMAC: "00:01:02:03:04:05"}))
+			Expect(s.LACP.State).To(Equal("down"))
+			Expect(s.LACP.Reason).To(Equal("toggled down"))
+			Expect(s.LACP.Actor).To(Equal(PortStatus{State: 61, Flags: []string{"activity", "aggregation", "synchronization", "collecting", "distributing"}}))
+			Expect(s.LACP.Partner).To(Equal(PortStatus{State: 13, Flags: []string{"activity", "aggregation", "synchronization"}}))
+			Expect(s.LastTransition.State).To(Equal("down"))
+			Expect(s.LastTransition.Reason).To(Equal("toggled down"))
+			Expect(s.LastTransition.Time).NotTo(BeZero())
+			Expect(s.VFs).To(Equal([]VFStatus{
+				{ID: 0, LinkState: "disable", IntendedLinkState: "disable", Owners: []Owner{{Actuator: "vfstate", Original: "auto"}}},
+				{ID: 1, LinkState: "disable", IntendedLinkState: "disable", Owners: []Owner{{Actuator: "vfstate", Original: "enable"}}},
+			}))
+
+			// The VFs are brought back to their original state once the PF is healthy.
+			healthy.Store(true)
+			mockNetlink.EXPECT().LinkSetVfState(gomock.Any(), 0, uint32(netlink.VF_LINK_STATE_AUTO)).Return(nil)
+			mockNetlink.EXPECT().LinkSetVfState(gomock.Any(), 1, uint32(netlink.VF_LINK_STATE_ENABLE)).Return(nil)
+			nics.poll()
+			link.Vfs[0].LinkState, link.Vfs[1].LinkState = netlink.VF_LINK_STATE_AUTO, netlink.VF_LINK_STATE_ENABLE
+
+			status := nics.Status()
+			Expect(status.PFs).To(HaveLen(1))
+			Expect(status.PFs[0].LACP.State).To(Equal("up"))
+			Expect(status.PFs[0].LastTransition.State).To(Equal("up"))
+			Expect(status.PFs[0].VFs).To(Equal([]VFStatus{
+				{ID: 0, LinkState: "auto", IntendedLinkState: "auto"},
+				{ID: 1, LinkState: "enable", IntendedLinkState: "enable"},
+			}))
+		})
+
+		It("should answer 404 for an interface that is not monitored", func() {
+			nics = &Nics{PFs: map[int]*pf.PF{}}
+
+			rec := httptest.NewRecorder()
+			nics.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/eth9", nil))
+			Expect(rec.Code).To(Equal(http.StatusNotFound))
+
+			rec = httptest.NewRecorder()
+			nics.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(Equal(`{"pfs":[]}` + "\n"))
+		})
+	})
+
+	Context("Readiness", func() {
+		It("should report every configured PF with the reason it is not ready", func() {
+			ready := &pf.PF{Name: "eth0", Index: 1, Ready: true}
+			ready.Publish()
+			notReady := &pf.PF{Name: "eth1", Index: 2, NotReadyReason: "link has no master interface"}
+			notReady.Publish()
+			nics = &Nics{
+				PFs:        map[int]*pf.PF{1: ready, 2: notReady},
+				interfaces: []string{"eth0", "eth1", "eth2", "eth3"},
+				missing:    map[string]string{"eth2": "failed to fetch interface: Link not found"},
+			}
+
+			Expect(nics.Readiness()).To(Equal([]health.Check{
+				{Name: "eth0", OK: true},
+				{Name: "eth1", Reason: "link has no master interface"},
+				{Name: "eth2", Reason: "failed to fetch interface: Link not found"},
+				{Name: "eth3", Reason: "interface was not found"},
+			}))
+		})
+
+		It("should record the reason the inspection failed", func() {
+			p := &pf.PF{Name: "eth0", Index: 1, OperState: netlink.OperDown}
+			p.Publish()
+			nics = &Nics{PFs: map[int]*pf.PF{1: p}, interfaces: []string{"eth0"}}
+			ctx, cancel := context.WithCancel(context.Background())
+			var wg sync.WaitGroup
+			nics.Inspect(ctx, &wg)
+			cancel()
+			wg.Wait()
+
+			Expect(nics.Readiness()).To(Equal([]health.Check{{Name: "eth0", Reason: "link is not up"}}))
+		})
+	})
+
+	Context("monitor with the number of VFs in sysfs", func() {
+		It("should only fetch the info of the VFs when they may change", func() {
+			sysfs := GinkgoT().TempDir()
+			device := filepath.Join(sysfs, "class", "net", "test", "device")
+			Expect(os.MkdirAll(device, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(device, "sriov_numvfs"), []byte("2\n"), 0o644)).To(Succeed())
+
+			var healthy atomic.Bool
+			healthy.Store(true)
+			p := &pf.PF{
+				Name:       "test",
+				Index:      1,
+				Ready:      true,
+				ProtoState: pf.Undefined,
+				Detector:   toggle{healthy: &healthy},
+				Actuator:   actuator.NewVfState("test", mockNetlink),
+				Nl:         mockNetlink,
+			}
+			nics = &Nics{PFs: map[int]*pf.PF{1: p}, nl: mockNetlink, sysfs: sysfs}
+
+			status := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test"}}
+			mockNetlink.EXPECT().LinkStatusByIndex(1).Return(status, nil).AnyTimes()
+
+			By("not fetching the VFs while the PF is healthy")
+			nics.monitor(p)
+			Expect(p.ProtoState).To(Equal(pf.Up))
+
+			By("fetching the VFs to disable them when the PF is not healthy")
+			healthy.Store(false)
+			full := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test", Vfs: []netlink.VfInfo{
+				{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO},
+				{ID: 1, LinkState: netlink.VF_LINK_STATE_ENABLE},
+			}}}
+			mockNetlink.EXPECT().LinkByIndex(1).Return(full, nil).Times(1)
+			mockNetlink.EXPECT().LinkSetVfState(full, gomock.Any(), uint32(netlink.VF_LINK_STATE_DISABLE)).Return(nil).Times(2)
+			nics.monitor(p)
+			Expect(p.ProtoState).To(Equal(pf.Down))
+
+			By("fetching the VFs to restore them when the PF recovers")
+			healthy.Store(true)
+			for i := range full.Vfs {
+				full.Vfs[i].LinkState = netlink.VF_LINK_STATE_DISABLE
+			}
+			mockNetlink.EXPECT().LinkByIndex(1).Return(full, nil).Times(1)
+			mockNetlink.EXPECT().LinkSetVfState(full, 0, uint32(netlink.VF_LINK_STATE_AUTO)).Return(nil).Times(1)
+			mockNetlink.EXPECT().LinkSetVfState(full, 1, uint32(netlink.VF_LINK_STATE_ENABLE)).Return(nil).Times(1)
+			nics.monitor(p)
+
+			By("not fetching the VFs once they are restored")
+			nics.monitor(p)
+			Expect(p.ProtoState).To(Equal(pf.Up))
+		})
+
+		It("should stop when the PF has no VFs", func() {
+			sysfs := GinkgoT().TempDir()
+			device := filepath.Join(sysfs, "class", "net", "test", "device")
+			Expect(os.MkdirAll(device, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(device, "sriov_numvfs"), []byte("0\n"), 0o644)).To(Succeed())
+
+			p := &pf.PF{Name: "test", Index: 1, Ready: true, ProtoState: pf.Undefined, Detector: detector.LACP{}}
+			nics = &Nics{PFs: map[int]*pf.PF{1: p}, nl: mockNetlink, sysfs: sysfs}
+			mockNetlink.EXPECT().LinkStatusByIndex(1).Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test"}}, nil).Times(1)
+
+			nics.monitor(p)
+			Expect(p.ProtoState).To(Equal(pf.NoVfs))
+		})
+	})
+
+	Context("poll with a link dump", func() {
+		It("should fetch every PF and their bond with a single dump", func() {
+			sysfs := GinkgoT().TempDir()
+			snapshot := interfaces.NewSnapshot(mockNetlink)
+			nics = &Nics{PFs: make(map[int]*pf.PF), nl: snapshot, snapshot: snapshot, sysfs: sysfs}
+
+			links := []netlink.Link{&netlink.Bond{LinkAttrs: netlink.LinkAttrs{Index: 100, Name: "bond0"}, Mode: netlink.BOND_MODE_802_3AD}}
+			for index := 1; index <= 8; index++ {
+				name := "test" + strconv.Itoa(index)
+				device := filepath.Join(sysfs, "class", "net", name, "device")
+				Expect(os.MkdirAll(device, 0o755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(device, "sriov_numvfs"), []byte("2\n"), 0o644)).To(Succeed())
+
+				nics.PFs[index] = &pf.PF{
+					Name:        name,
+					Index:       index,
+					OperState:   netlink.OperUp,
+					MasterIndex: 100,
+					Ready:       true,
+					ProtoState:  pf.Undefined,
+					Detector:    detector.Carrier{},
+					Actuator:    actuator.NewVfState(name, snapshot),
+					Nl:          snapshot,
+				}
+				links = append(links, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: index, Name: name, OperState: netlink.OperUp, MasterIndex: 100}})
+			}
+			mockNetlink.EXPECT().LinkStatusList().Return(links, nil).Times(1)
+
+			nics.poll()
+			for _, p := range nics.PFs {
+				Expect(p.ProtoState).To(Equal(pf.Up))
+				Expect(p.Inspect()).To(Succeed())
+			}
+		})
+
+		It("should monitor the PFs in parallel", func() {
+			sysfs := GinkgoT().TempDir()
+			nics = &Nics{PFs: make(map[int]*pf.PF), nl: mockNetlink, sysfs: sysfs}
+
+			// Every detector waits for the others, which only returns when the PFs are monitored in parallel.
+			var started sync.WaitGroup
+			started.Add(4)
+			for index := 1; index <= 4; index++ {
+				name := "test" + strconv.Itoa(index)
+				nics.PFs[index] = &pf.PF{Name: name, Index: index, Ready: true, ProtoState: pf.Undefined, Detector: barrier{started: &started}, Actuator: actuator.NewVfState(name, mockNetlink)}
+				mockNetlink.EXPECT().LinkByIndex(index).Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{
+					Index: index, Name: name, Vfs: []netlink.VfInfo{{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO}},
+				}}, nil).Times(1)
+			}
+
+			nics.poll()
+			for _, p := range nics.PFs {
+				Expect(p.ProtoState).To(Equal(pf.Up))
+			}
+		})
+
+		It("should fetch the PFs one by one when the dump fails", func() {
+			p := &pf.PF{Name: "test", Index: 1, Ready: true, ProtoState: pf.Undefined, Detector: detector.LACP{}}
+			snapshot := interfaces.NewSnapshot(mockNetlink)
+			nics = &Nics{PFs: map[int]*pf.PF{1: p}, nl: snapshot, snapshot: snapshot, sysfs: GinkgoT().TempDir()}
+
+			mockNetlink.EXPECT().LinkStatusList().Return(nil, errors.New("dump interrupted")).Times(1)
+			mockNetlink.EXPECT().LinkByIndex(1).Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test"}}, nil).Times(1)
+
+			nics.poll()
+			Expect(p.ProtoState).To(Equal(pf.NoVfs))
+			Expect(logBuf.String()).To(ContainSubstring(`"msg":"failed to dump links","error":"dump interrupted"`))
+		})
+	})
+
+	Context("Monitor with a failing actuator", func() {
+		It("should raise the actuation failed condition until the VF state is applied", func() {
+			var (
+				mu     sync.Mutex
+				state  = uint32(netlink.VF_LINK_STATE_AUTO)
+				broken atomic.Bool
+			)
+			broken.Store(true)
+
+			mockNetlink.EXPECT().LinkByIndex(1).DoAndReturn(func(int) (netlink.Link, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				return &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{
+					Index: 1,
+					Name:  "test",
+					Vfs:   []netlink.VfInfo{{ID: 0, LinkState: state}},
+				}}, nil
+			}).AnyTimes()
+			// The state is silently ignored while the driver is broken.
+			mockNetlink.EXPECT().LinkSetVfState(gomock.Any(), 0, gomock.Any()).DoAndReturn(func(_ netlink.Link, _ int, s uint32) error {
+				mu.Lock()
+				defer mu.Unlock()
+				if !broken.Load() {
+					state = s
+				}
+				return nil
+			}).AnyTimes()
+
+			a := actuator.NewVfState("test", mockNetlink)
+			a.SetRetry(actuator.Retry{Attempts: 2, Backoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond, Verify: true})
+			var healthy atomic.Bool
+			nics = &Nics{
+				PFs: map[int]*pf.PF{
+					1: {
+						Name:       "test",
+						Index:      1,
+						Ready:      true,
+						ProtoState: pf.Undefined,
+						Detector:   toggle{healthy: &healthy},
+						Actuator:   a,
+						Nl:         mockNetlink,
+					},
+				},
+				nl:              mockNetlink,
+				pollingInterval: 100,
+			}
+			actuationError := func() error {
+				nics.PFs[1].Lock()
+				defer nics.PFs[1].Unlock()
+				return nics.PFs[1].ActuationError
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			wg := &sync.WaitGroup{}
+			nics.Monitor(ctx, wg)
+
+			Eventually(actuationError, "1s", "50ms").Should(MatchError(actuator.ErrNotApplied))
+			Expect(nics.PFs[1].ActuationFailedSince).NotTo(BeZero())
+
+			broken.Store(false)
+			Eventually(actuationError, "1s", "50ms").Should(BeNil())
+
+			cancel()
+			wg.Wait()
+
+			Expect(nics.PFs[1].ActuationFailedSince).To(BeZero())
+			Expect(strings.Count(logBuf.String(), `"msg":"actuation failed"`)).To(Equal(1))
+			Expect(logBuf.String()).To(ContainSubstring(`"msg":"actuation recovered"`))
+		})
+	})
+
+	Context("Monitor with a hung actuator", func() {
+		It("should be reported as stalled by /healthz", func() {
+			mockNetlink.EXPECT().LinkByIndex(1).Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{
+				Index: 1,
+				Name:  "test",
+				Vfs: []netlink.VfInfo{
+					{ID: 0, LinkState: netlink.VF_LINK_STATE_AUTO},
+					{ID: 1, LinkState: netlink.VF_LINK_STATE_AUTO},
+					{ID: 2, LinkState: netlink.VF_LINK_STATE_AUTO},
+					{ID: 3, LinkState: netlink.VF_LINK_STATE_AUTO},
+				},
+			}}, nil).AnyTimes()
+			// The first VFs are set slowly, which is progress even though they take longer than the stall timeout
+			// altogether, while the last one hangs.
+			hung := make(chan struct{})
+			mockNetlink.EXPECT().LinkSetVfState(gomock.Any(), gomock.Not(3), gomock.Any()).DoAndReturn(func(netlink.Link, int, uint32) error {
+				time.Sleep(150 * time.Millisecond)
+				return nil
+			}).Times(3)
+			mockNetlink.EXPECT().LinkSetVfState(gomock.Any(), 3, gomock.Any()).DoAndReturn(func(netlink.Link, int, uint32) error {
+				<-hung
+				return nil
+			}).Times(1)
+
+			var healthy atomic.Bool
+			a := actuator.NewVfState("test", mockNetlink)
+			nics = &Nics{
+				PFs: map[int]*pf.PF{
+					1: {
+						Name:       "test",
+						Index:      1,
+						Ready:      true,
+						ProtoState: pf.Undefined,
+						Detector:   toggle{healthy: &healthy},
+						Actuator:   a,
+						Nl:         mockNetlink,
+					},
+				},
+				nl:              mockNetlink,
+				pollingInterval: 10,
+				heartbeat:       health.NewHeartbeat("monitor", 300*time.Millisecond),
+			}
+			nics.configure(a)
+
+			handler := health.Handler(health.Liveness(nics.Heartbeat()))
+			code := func() int {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+				return rec.Code
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			wg := &sync.WaitGroup{}
+			nics.Monitor(ctx, wg)
+
+			Consistently(code, "400ms", "10ms").Should(Equal(http.StatusOK))
+			Eventually(code, "1s", "10ms").Should(Equal(http.StatusServiceUnavailable))
+
+			cancel()
+			close(hung)
+			wg.Wait()
+		})
+	})
+
+	Context("New with a vf priority", func() {
+		It("should not monitor a PF whose vf priority is invalid", func() {
+			mockNetlink.EXPECT().LinkByName("test").Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test"}}, nil)
+
+			conf := config.Config{Interfaces: []string{"test"}}
+			conf.Defaults.Priority.IDs = map[string]int{"x": 10}
+			nics := New(conf, nil, mockNetlink)
+			Expect(nics.PFs).To(BeEmpty())
+			Expect(logBuf.String()).To(ContainSubstring(`invalid vf priority: invalid vf id \"x\"`))
+		})
+	})
+
+	Context("New with a state journal", func() {
+		It("should warn that the journal is disabled when it cannot be opened", func() {
+			mockNetlink.EXPECT().LinkByName("test").Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 1, Name: "test"}}, nil)
+
+			// The state file is a directory, which cannot be read.
+			conf := config.Config{Interfaces: []string{"test"}, StateFile: GinkgoT().TempDir()}
+			nics := New(conf, nil, mockNetlink)
+			Expect(nics.journal).To(BeNil())
+			Expect(logBuf.String()).To(ContainSubstring(`"level":"WARN","msg":"failed to open state journal, the VFs disabled by this instance will not be restored after a restart"`))
+		})
+
+		It("should restore the VFs owned by a previous instance", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "state.json")
+			j, err := journal.Open(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(j.Update("test", "vfstate", journal.Owned{0: netlink.VF_LINK_STATE_ENABLE, 1: netlink.VF_LINK_STATE_AUTO})).To(Succeed())
+			Expect(j.Update("removed", "vfstate", journal.Owned{0: netlink.VF_LINK_STATE_AUTO})).To(Succeed())
+
+			// VF 1 was brought back by someone else while the relay was not running.
+			mockNetlink.EXPECT().LinkByName("test").Return(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{
+				Index: 1,
+				Name:  "test",
+				Vfs: []netlink.VfInfo{
+					{ID: 0, LinkState: netlink.VF_LINK_STATE_DISABLE},
+					{ID: 1, LinkState: netlink.VF_LINK_STATE_AUTO},
+				},
+			}}, nil)
+
+			nics := New(config.Config{Interfaces: []string{"test"}, StateFile: path}, nil, mockNetlink)
+			Expect(nics.PFs).To(HaveKey(1))
+			Expect(nics.PFs[1].Actuator.(*actuator.VfState).Owned()).To(Equal(map[int]uint32{0: netlink.VF_LINK_STATE_ENABLE}))
+
+			By("reconciling the journal")
+			j, err = journal.Open(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(j.Owned("test", "vfstate")).To(Equal(journal.Owned{0: netlink.VF_LINK_STATE_ENABLE}))
+			Expect(j.Owned("removed", "vfstate")).To(BeEmpty())
+		})
+	})
+
+	Context("Shutdown", func() {
+		DescribeTable("should apply the shutdown policy to the VFs owned by the relay",
+			func(policy config.ShutdownPolicy, healthy, restored bool) {
+				link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{
+					Index: 1,
+					Name:  "test",
+					Vfs:   []netlink.VfInfo{{ID: 0, LinkState: netlink.VF_LINK_STATE_DISABLE}},
+				}}
+				vfState := actuator.NewVfState("test", mockNetlink)
+				vfState.Restore(link, map[int]uint32{0: netlink.VF_LINK_STATE_ENABLE})
+
+				nics = &Nics{
+					PFs: map[int]*pf.PF{
+						1: {
+							Name:     "test",
+							Index:    1,
+							Health:   detector.Status{Healthy: healthy},
+							Actuator: vfState,
+						},
+					},
+					nl:              mockNetlink,
+					shutdownPolicy:  policy,
+					shutdownTimeout: time.Second,
+				}
+
+				if restored {
+					mockNetlink.EXPECT().LinkByIndex(1).Return(link, nil).Times(1)
+					mockNetlink.EXPECT().LinkSetVfState(link, 0, uint32(netlink.VF_LINK_STATE_ENABLE)).Return(nil).Times(1)
+				}
+
+				nics.Shutdown()
+
+				if restored {
+					Expect(vfState.Owned()).To(BeEmpty())
+					Expect(logBuf.String()).To(ContainSubstring(`"msg":"vf link state was set","id":0,"state":"enable","interface":"test"`))
+				} else {
+					Expect(vfState.Owned()).To(HaveLen(1))
+					Expect(logBuf.String()).To(ContainSubstring(`"msg":"vf is left disabled","id":0,"interface":"test","policy":"` + string(policy) + `"`))
+				}
+			},
+			Entry("leave", config.ShutdownLeave, true, false),
+			Entry("restore", config.ShutdownRestore, false, true),
+			Entry("restore if up when the PF is healthy", config.ShutdownRestoreIfUp, true, true),
+			Entry("restore if up when the PF is not healthy", config.ShutdownRestoreIfUp, false, false),
+		)
+	})
+
 	Context("Inspect", func() {
 		BeforeEach(func() {
 			nics = &Nics{
@@ -262,3 +958,43 @@ var _ = Describe("LACP", func() {
 		})
 	})
 })
+
+type toggle struct {
+	healthy *atomic.Bool
+}
+
+func (t toggle) Name() string {
+	return "toggle"
+}
+
+func (t toggle) Detect(netlink.Link) (detector.Status, error) {
+	if t.healthy.Load() {
+		return detector.Status{Healthy: true}, nil
+	}
+
+	return detector.Status{Reason: "toggled down"}, nil
+}
+
+type barrier struct {
+	started *sync.WaitGroup
+}
+
+func (b barrier) Name() string {
+	return "barrier"
+}
+
+func (b barrier) Detect(netlink.Link) (detector.Status, error) {
+	b.started.Done()
+	done := make(chan struct{})
+	go func() {
+		b.started.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return detector.Status{Healthy: true}, nil
+	case <-time.After(time.Second):
+		return detector.Status{}, errors.New("pfs are not monitored in parallel")
+	}
+}
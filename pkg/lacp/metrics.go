@@ -0,0 +1,107 @@
+package lacp
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/lacp/flags"
+	"github.com/openshift/pf-status-relay/pkg/lacp/pf"
+	"github.com/openshift/pf-status-relay/pkg/log"
+	"github.com/openshift/pf-status-relay/pkg/metrics"
+)
+
+// lacpStates are the LACP states of a PF exposed by the metrics.
+var lacpStates = []string{"up", "down", "novfs", "undefined"}
+
+// vfStates are the names of the link states of VFs exposed by the metrics.
+var vfStates = map[uint32]string{
+	netlink.VF_LINK_STATE_AUTO:    "auto",
+	netlink.VF_LINK_STATE_ENABLE:  "enable",
+	netlink.VF_LINK_STATE_DISABLE: "disable",
+}
+
+// Register adds the metrics describing the state of the PFs to r. They are collected from the state published by
+// the last poll of every PF, except for the states of the VFs which are fetched when the metrics are written.
+func (i *Nics) Register(r *metrics.Registry) {
+	r.Register(metrics.NewGauge("pf_status_relay_lacp_state", "LACP state of PFs, 1 for the current state.", func(emit func(float64, ...string)) {
+		for _, s := range i.states() {
+			for _, state := range lacpStates {
+				emit(bool2float(s.ProtoState.String() == state), s.Name, state)
+			}
+		}
+	}, "interface", "state"))
+
+	r.Register(metrics.NewGauge("pf_status_relay_lacp_port_state", "LACP port state flags of PFs and their partners.", func(emit func(float64, ...string)) {
+		for _, s := range i.states() {
+			for bit, flag := range flags.Names {
+				emit(float64(s.ActorPortState>>bit&1), s.Name, "actor", flag)
+				emit(float64(s.PartnerPortState>>bit&1), s.Name, "partner", flag)
+			}
+		}
+	}, "interface", "side", "flag"))
+
+	r.Register(metrics.NewGauge("pf_status_relay_ready", "Whether PFs are ready to be monitored.", func(emit func(float64, ...string)) {
+		for _, s := range i.states() {
+			emit(bool2float(s.Ready), s.Name)
+		}
+	}, "interface"))
+
+	r.Register(metrics.NewGauge("pf_status_relay_actuation_failed", "Whether relaying the health of PFs to their VFs is failing.", func(emit func(float64, ...string)) {
+		for _, s := range i.states() {
+			emit(bool2float(s.ActuationError != nil), s.Name)
+		}
+	}, "interface"))
+
+	r.Register(metrics.NewGauge("pf_status_relay_actuation_failed_since_seconds", "Time at which relaying the health of PFs to their VFs started failing, in seconds since the epoch.", func(emit func(float64, ...string)) {
+		for _, s := range i.states() {
+			if s.ActuationError != nil {
+				emit(float64(s.ActuationFailedSince.UnixNano())/1e9, s.Name)
+			}
+		}
+	}, "interface"))
+
+	r.Register(metrics.NewGauge("pf_status_relay_vfs", "Number of VFs of PFs by link state.", func(emit func(float64, ...string)) {
+		for _, s := range i.states() {
+			link, err := i.nl.LinkByIndex(s.Index)
+			if err != nil {
+				log.Log.Warn("failed to fetch interface", "interface", s.Name, "error", err)
+				continue
+			}
+
+			counts := make(map[string]int)
+			for _, vf := range link.Attrs().Vfs {
+				counts[vfStateName(vf.LinkState)]++
+			}
+			for _, state := range []string{"auto", "enable", "disable"} {
+				emit(float64(counts[state]), s.Name, state)
+				delete(counts, state)
+			}
+			for state, count := range counts {
+				emit(float64(count), s.Name, state)
+			}
+		}
+	}, "interface", "state"))
+}
+
+// states returns the published states of the PFs sorted by name.
+func (i *Nics) states() []pf.State {
+	states := make([]pf.State, 0, len(i.PFs))
+	for _, p := range i.PFs {
+		states = append(states, p.State())
+	}
+	slices.SortFunc(states, func(a, b pf.State) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return states
+}
+
+func bool2float(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -3,9 +3,12 @@ package pf
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/vishvananda/netlink"
 
+	"github.com/openshift/pf-status-relay/pkg/actuator"
+	"github.com/openshift/pf-status-relay/pkg/detector"
 	"github.com/openshift/pf-status-relay/pkg/interfaces"
 	"github.com/openshift/pf-status-relay/pkg/log"
 )
@@ -23,21 +26,131 @@ type PF struct {
 
 	sync.Mutex
 	Ready bool
-
-	ProtoState protoState
+	// NotReadyReason is the reason the last inspection of the PF failed.
+	NotReadyReason string
+
+	ProtoState ProtoState
+
+	// Detector determines the health of the PF.
+	Detector detector.Detector
+	// Health is the last status reported by Detector.
+	Health detector.Status
+	// ActorPortState and PartnerPortState are the LACP port states of the PF and its partner seen by the last poll.
+	ActorPortState   uint8
+	PartnerPortState uint16
+	// HoldDownUntil is the time at which the VFs are brought back after the PF recovered.
+	HoldDownUntil time.Time
+	// TransitionTime and TransitionReason are the time and the reason of the last change of ProtoState.
+	TransitionTime   time.Time
+	TransitionReason string
+	// Actuator relays the health of the PF to its VFs.
+	Actuator actuator.Actuator
+	// ActuationError is the error of the last relay of the health of the PF when it failed. It is cleared once
+	// the relay succeeds.
+	ActuationError error
+	// ActuationFailedSince is the time at which relaying the health of the PF started failing.
+	ActuationFailedSince time.Time
+	// ActuationDuration is the time it took to relay the last change of health of the PF to all of its VFs.
+	ActuationDuration time.Duration
 
 	Nl interfaces.Netlink
+
+	state State
+}
+
+// State is the state of a PF as seen by its last poll. It can be read while the PF is monitored.
+type State struct {
+	Name                 string
+	Index                int
+	MasterIndex          int
+	Ready                bool
+	NotReadyReason       string
+	ProtoState           ProtoState
+	ActorPortState       uint8
+	PartnerPortState     uint16
+	Health               detector.Status
+	HoldDownUntil        time.Time
+	TransitionTime       time.Time
+	TransitionReason     string
+	ActuationError       error
+	ActuationFailedSince time.Time
+	ActuationDuration    time.Duration
+	// Owned maps the name of the stateful actuators of the PF to the VFs they own and their original state.
+	Owned map[string]map[int]uint32
+}
+
+// Publish makes the state of the PF available to State. It must be called by the routine monitoring the PF.
+func (p *PF) Publish() {
+	owned := make(map[string]map[int]uint32)
+	for _, a := range actuator.Unwrap(p.Actuator) {
+		if s, ok := a.(actuator.Stateful); ok {
+			owned[a.Name()] = s.Owned()
+		}
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.state = State{
+		Name:                 p.Name,
+		Index:                p.Index,
+		MasterIndex:          p.MasterIndex,
+		Ready:                p.Ready,
+		NotReadyReason:       p.NotReadyReason,
+		ProtoState:           p.ProtoState,
+		ActorPortState:       p.ActorPortState,
+		PartnerPortState:     p.PartnerPortState,
+		Health:               p.Health,
+		HoldDownUntil:        p.HoldDownUntil,
+		TransitionTime:       p.TransitionTime,
+		TransitionReason:     p.TransitionReason,
+		ActuationError:       p.ActuationError,
+		ActuationFailedSince: p.ActuationFailedSince,
+		ActuationDuration:    p.ActuationDuration,
+		Owned:                owned,
+	}
 }
 
-type protoState int
+// State returns the state of the PF published by its last poll.
+func (p *PF) State() State {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.state
+}
+
+// Readiness returns whether the PF passed its last inspection, or the reason it failed.
+func (p *PF) Readiness() (bool, string) {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.Ready, p.NotReadyReason
+}
+
+// ProtoState is the state of the LACP protocol of a PF.
+type ProtoState int
 
 const (
-	Up protoState = iota
+	Up ProtoState = iota
 	Down
 	NoVfs
 	Undefined
 )
 
+// String returns the name of the state.
+func (s ProtoState) String() string {
+	switch s {
+	case Up:
+		return "up"
+	case Down:
+		return "down"
+	case NoVfs:
+		return "novfs"
+	}
+
+	return "undefined"
+}
+
 func (p *PF) Inspect() error {
 	// Verify that link is up.
 	if p.OperState != netlink.OperUp {
@@ -0,0 +1,271 @@
+package lacp
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/openshift/pf-status-relay/pkg/actuator"
+	"github.com/openshift/pf-status-relay/pkg/lacp/flags"
+	"github.com/openshift/pf-status-relay/pkg/lacp/pf"
+)
+
+// Status is the view of the relay on the PFs and their VFs.
+type Status struct {
+	PFs []PFStatus `json:"pfs"`
+}
+
+// PFStatus is the status of a PF.
+type PFStatus struct {
+	Name           string      `json:"name"`
+	Index          int         `json:"index"`
+	Bond           *BondStatus `json:"bond,omitempty"`
+	Ready          bool        `json:"ready"`
+	NotReadyReason string      `json:"notReadyReason,omitempty"`
+	LACP           LACPStatus  `json:"lacp"`
+	// HoldDownUntil is the time at which the VFs are brought back after the PF recovered.
+	HoldDownUntil        *time.Time  `json:"holdDownUntil,omitempty"`
+	LastTransition       *Transition `json:"lastTransition,omitempty"`
+	ActuationError       string      `json:"actuationError,omitempty"`
+	ActuationFailedSince *time.Time  `json:"actuationFailedSince,omitempty"`
+	VFs                  []VFStatus  `json:"vfs"`
+	// Errors are the failures to fetch the PF or its bond, which leave their part of the status empty.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// BondStatus is the status of the bond of a PF, along with the LACP partner of its active aggregator.
+type BondStatus struct {
+	Name         string `json:"name"`
+	Index        int    `json:"index"`
+	Mode         string `json:"mode,omitempty"`
+	AggregatorID int    `json:"aggregatorId,omitempty"`
+	NumPorts     int    `json:"numPorts,omitempty"`
+	ActorKey     int    `json:"actorKey,omitempty"`
+	PartnerKey   int    `json:"partnerKey,omitempty"`
+	PartnerMAC   string `json:"partnerMac,omitempty"`
+}
+
+// LACPStatus is the LACP state of a PF and the health reported by its detector.
+type LACPStatus struct {
+	State      string            `json:"state"`
+	Healthy    bool              `json:"healthy"`
+	Reason     string            `json:"reason,omitempty"`
+	Warnings   []string          `json:"warnings,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Actor      PortStatus        `json:"actor"`
+	Partner    PortStatus        `json:"partner"`
+}
+
+// PortStatus is a LACP port state along with the names of its flags.
+type PortStatus struct {
+	State uint16   `json:"state"`
+	Flags []string `json:"flags"`
+}
+
+// Transition is the last change of the LACP state of a PF.
+type Transition struct {
+	Time   time.Time `json:"time"`
+	State  string    `json:"state"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// VFStatus is the status of a VF.
+type VFStatus struct {
+	ID  int    `json:"id"`
+	MAC string `json:"mac,omitempty"`
+	// LinkState is the current link state of the VF, and IntendedLinkState the one the relay brings it to.
+	LinkState         string  `json:"linkState"`
+	IntendedLinkState string  `json:"intendedLinkState"`
+	Owners            []Owner `json:"owners,omitempty"`
+}
+
+// Owner is an actuator that changed the state of a VF, along with the state it is restored to.
+type Owner struct {
+	Actuator string `json:"actuator"`
+	Original string `json:"original"`
+}
+
+// Status returns the status of the PFs sorted by name. It is built from the state published by the last poll of
+// every PF, except for the bonds and the VFs which are fetched.
+func (i *Nics) Status() Status {
+	states := i.states()
+	status := Status{PFs: make([]PFStatus, 0, len(states))}
+	for _, s := range states {
+		status.PFs = append(status.PFs, i.status(s))
+	}
+
+	return status
+}
+
+// status returns the status of the PF with the given published state.
+func (i *Nics) status(s pf.State) PFStatus {
+	p := i.PFs[s.Index]
+	ready, reason := p.Readiness()
+	status := PFStatus{
+		Name:           s.Name,
+		Index:          s.Index,
+		Ready:          ready,
+		NotReadyReason: reason,
+		LACP: LACPStatus{
+			State:      s.ProtoState.String(),
+			Healthy:    s.Health.Healthy,
+			Reason:     s.Health.Reason,
+			Warnings:   s.Health.Warnings,
+			Attributes: s.Health.Attributes,
+			Actor:      PortStatus{State: uint16(s.ActorPortState), Flags: flags.Decode(uint16(s.ActorPortState))},
+			Partner:    PortStatus{State: s.PartnerPortState, Flags: flags.Decode(s.PartnerPortState)},
+		},
+		HoldDownUntil:        timeOrNil(s.HoldDownUntil),
+		ActuationFailedSince: timeOrNil(s.ActuationFailedSince),
+		VFs:                  []VFStatus{},
+	}
+	if !s.TransitionTime.IsZero() {
+		status.LastTransition = &Transition{Time: s.TransitionTime, State: s.ProtoState.String(), Reason: s.TransitionReason}
+	}
+	if s.ActuationError != nil {
+		status.ActuationError = s.ActuationError.Error()
+	}
+
+	if s.MasterIndex != 0 {
+		bond, err := i.nl.LinkByIndex(s.MasterIndex)
+		if err != nil {
+			status.Errors = append(status.Errors, "failed to fetch bond: "+err.Error())
+		} else {
+			status.Bond = bondStatus(bond)
+		}
+	}
+
+	link, err := i.nl.LinkByIndex(s.Index)
+	if err != nil {
+		status.Errors = append(status.Errors, "failed to fetch interface: "+err.Error())
+		return status
+	}
+	for _, vf := range link.Attrs().Vfs {
+		status.VFs = append(status.VFs, VFStatus{
+			ID:                vf.ID,
+			MAC:               vf.Mac.String(),
+			LinkState:         vfStateName(vf.LinkState),
+			IntendedLinkState: vfStateName(intended(s, p.Actuator, vf)),
+			Owners:            owners(s, p.Actuator, vf.ID),
+		})
+	}
+
+	return status
+}
+
+// bondStatus returns the status of a bond.
+func bondStatus(link netlink.Link) *BondStatus {
+	status := &BondStatus{Name: link.Attrs().Name, Index: link.Attrs().Index}
+	bond, ok := link.(*netlink.Bond)
+	if !ok {
+		return status
+	}
+
+	status.Mode = bond.Mode.String()
+	if bond.AdInfo != nil {
+		status.AggregatorID = bond.AdInfo.AggregatorId
+		status.NumPorts = bond.AdInfo.NumPorts
+		status.ActorKey = bond.AdInfo.ActorKey
+		status.PartnerKey = bond.AdInfo.PartnerKey
+		status.PartnerMAC = bond.AdInfo.PartnerMac.String()
+	}
+
+	return status
+}
+
+// intended returns the link state the actuators of a PF with the published state s bring vf to. The VFs that are
+// not selected are left alone, apart from those owned by an actuator, which are still restored.
+func intended(s pf.State, a actuator.Actuator, vf netlink.VfInfo) uint32 {
+	selected := actuator.Selected(a, vf)
+	for _, a := range actuator.Unwrap(a) {
+		v, ok := a.(*actuator.VfState)
+		if !ok {
+			continue
+		}
+
+		healthy, set := v.HealthyState()
+		original, owned := s.Owned[v.Name()][vf.ID]
+		switch {
+		case s.ProtoState == pf.Down && (selected || owned):
+			return netlink.VF_LINK_STATE_DISABLE
+		case s.ProtoState != pf.Up:
+		case owned && set:
+			return healthy
+		case owned:
+			return original
+		case set && selected && vf.LinkState != netlink.VF_LINK_STATE_DISABLE:
+			return healthy
+		}
+	}
+
+	return vf.LinkState
+}
+
+// owners returns the actuators of a PF with the published state s that own the VF with the given id.
+func owners(s pf.State, a actuator.Actuator, id int) []Owner {
+	var owners []Owner
+	for _, a := range actuator.Unwrap(a) {
+		original, ok := s.Owned[a.Name()][id]
+		if !ok {
+			continue
+		}
+
+		name := strconv.FormatUint(uint64(original), 10)
+		if n, ok := a.(interface{ StateName(uint32) string }); ok {
+			name = n.StateName(original)
+		}
+		owners = append(owners, Owner{Actuator: a.Name(), Original: name})
+	}
+
+	return owners
+}
+
+// StatusHandler returns an HTTP handler that serves the status of all PFs on /status, and the status of a single
+// PF on /status/{interface}.
+func (i *Nics) StatusHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, i.Status())
+	})
+	mux.HandleFunc("GET /status/{interface}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("interface")
+		states := i.states()
+		index := slices.IndexFunc(states, func(s pf.State) bool { return s.Name == name })
+		if index < 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "interface " + name + " is not monitored"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, i.status(states[index]))
+	})
+
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// vfStateName returns the name of the link state of a VF.
+func vfStateName(state uint32) string {
+	name, ok := vfStates[state]
+	if !ok {
+		return strconv.FormatUint(uint64(state), 10)
+	}
+
+	return name
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
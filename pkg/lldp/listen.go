@@ -0,0 +1,92 @@
+package lldp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+// filter only accepts frames whose ethertype is LLDP.
+var filter = []unix.SockFilter{
+	{Code: unix.BPF_LD | unix.BPF_H | unix.BPF_ABS, K: 12},
+	{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: 0, Jf: 1, K: EtherType},
+	{Code: unix.BPF_RET | unix.BPF_K, K: 0xffff},
+	{Code: unix.BPF_RET | unix.BPF_K, K: 0},
+}
+
+// Listen receives LLDP frames on the interface until ctx is cancelled, calling handle for every valid frame.
+// Frames are captured before they reach a bond master, so it can be used on bond slaves.
+func Listen(ctx context.Context, iface string, handle func(Neighbor)) error {
+	i, err := net.InterfaceByName(iface)
+	if err != nil {
+		return err
+	}
+
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, int(htons(unix.ETH_P_ALL)))
+	if err != nil {
+		return fmt.Errorf("failed to open packet socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: unsafe.SliceData(filter)}
+	err = unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &prog)
+	if err != nil {
+		return fmt.Errorf("failed to attach lldp filter: %w", err)
+	}
+
+	err = unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_ALL), Ifindex: i.Index})
+	if err != nil {
+		return fmt.Errorf("failed to bind packet socket to %s: %w", iface, err)
+	}
+
+	mreq := unix.PacketMreq{Ifindex: int32(i.Index), Type: unix.PACKET_MR_MULTICAST, Alen: uint16(len(Multicast))}
+	copy(mreq.Address[:], Multicast)
+	err = unix.SetsockoptPacketMreq(fd, unix.SOL_PACKET, unix.PACKET_ADD_MEMBERSHIP, &mreq)
+	if err != nil {
+		return fmt.Errorf("failed to join lldp multicast group on %s: %w", iface, err)
+	}
+
+	// Wake up periodically to honour ctx.
+	tv := unix.NsecToTimeval((500 * time.Millisecond).Nanoseconds())
+	err = unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 1514)
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		n, from, err := unix.Recvfrom(fd, buf, 0)
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if ll, ok := from.(*unix.SockaddrLinklayer); ok && ll.Pkttype == unix.PACKET_OUTGOING {
+			continue
+		}
+
+		neighbor, err := Decode(buf[:n])
+		if err != nil {
+			log.Log.Debug("discarding invalid lldp frame", "interface", iface, "error", err)
+			continue
+		}
+		handle(neighbor)
+	}
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
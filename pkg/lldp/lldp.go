@@ -0,0 +1,153 @@
+package lldp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// EtherType is the ethertype of LLDP frames.
+const EtherType = 0x88cc
+
+// Multicast is the nearest bridge group address where LLDP frames are sent to.
+var Multicast = net.HardwareAddr{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+
+const (
+	tlvEnd               = 0
+	tlvChassisID         = 1
+	tlvPortID            = 2
+	tlvTTL               = 3
+	tlvSystemName        = 5
+	tlvManagementAddress = 8
+
+	chassisIDMACAddress     = 4
+	chassisIDNetworkAddress = 5
+	portIDMACAddress        = 3
+	portIDNetworkAddress    = 4
+
+	addressFamilyIPv4 = 1
+	addressFamilyIPv6 = 2
+)
+
+// Neighbor is the information advertised by an LLDP neighbor.
+type Neighbor struct {
+	ChassisID         string
+	PortID            string
+	SystemName        string
+	ManagementAddress string
+	// TTL is the time the information is valid for.
+	TTL time.Duration
+}
+
+// Attributes returns the neighbor as a map.
+func (n Neighbor) Attributes() map[string]string {
+	attrs := map[string]string{
+		"chassis_id": n.ChassisID,
+		"port_id":    n.PortID,
+	}
+	if n.SystemName != "" {
+		attrs["system_name"] = n.SystemName
+	}
+	if n.ManagementAddress != "" {
+		attrs["management_address"] = n.ManagementAddress
+	}
+
+	return attrs
+}
+
+// Decode decodes an ethernet frame carrying an LLDPDU.
+func Decode(frame []byte) (Neighbor, error) {
+	if len(frame) < 14 {
+		return Neighbor{}, fmt.Errorf("frame too short: %d bytes", len(frame))
+	}
+	if t := binary.BigEndian.Uint16(frame[12:]); t != EtherType {
+		return Neighbor{}, fmt.Errorf("unexpected ethertype 0x%04x", t)
+	}
+
+	var n Neighbor
+	var seen [tlvTTL + 1]bool
+	b := frame[14:]
+	for len(b) >= 2 {
+		header := binary.BigEndian.Uint16(b)
+		typ, length := int(header>>9), int(header&0x1ff)
+		if len(b) < 2+length {
+			return Neighbor{}, fmt.Errorf("tlv %d is truncated", typ)
+		}
+		value := b[2 : 2+length]
+		b = b[2+length:]
+
+		// The mandatory TLVs must be the first ones and in order.
+		if typ <= tlvTTL && typ != tlvEnd {
+			for i := tlvChassisID; i < typ; i++ {
+				if !seen[i] {
+					return Neighbor{}, fmt.Errorf("tlv %d before tlv %d", typ, i)
+				}
+			}
+			if seen[typ] {
+				return Neighbor{}, fmt.Errorf("duplicated tlv %d", typ)
+			}
+			seen[typ] = true
+		} else if !seen[tlvTTL] && typ != tlvEnd {
+			return Neighbor{}, fmt.Errorf("tlv %d before mandatory tlvs", typ)
+		}
+
+		switch typ {
+		case tlvEnd:
+			b = nil
+		case tlvChassisID:
+			if length < 2 {
+				return Neighbor{}, fmt.Errorf("invalid chassis id length %d", length)
+			}
+			n.ChassisID = id(value[0], value[1:], chassisIDMACAddress, chassisIDNetworkAddress)
+		case tlvPortID:
+			if length < 2 {
+				return Neighbor{}, fmt.Errorf("invalid port id length %d", length)
+			}
+			n.PortID = id(value[0], value[1:], portIDMACAddress, portIDNetworkAddress)
+		case tlvTTL:
+			if length != 2 {
+				return Neighbor{}, fmt.Errorf("invalid ttl length %d", length)
+			}
+			n.TTL = time.Duration(binary.BigEndian.Uint16(value)) * time.Second
+		case tlvSystemName:
+			n.SystemName = string(value)
+		case tlvManagementAddress:
+			// Only the first management address is kept.
+			if n.ManagementAddress == "" && length >= 2 && int(value[0]) >= 2 && length >= 1+int(value[0]) {
+				n.ManagementAddress = address(value[1], value[2:1+int(value[0])])
+			}
+		}
+	}
+
+	if !seen[tlvTTL] {
+		return Neighbor{}, fmt.Errorf("missing mandatory tlvs")
+	}
+
+	return n, nil
+}
+
+// id formats a chassis or port id according to its subtype.
+func id(subtype byte, value []byte, macSubtype, addressSubtype byte) string {
+	switch {
+	case subtype == macSubtype && len(value) == 6:
+		return net.HardwareAddr(value).String()
+	case subtype == addressSubtype && len(value) > 1:
+		return address(value[0], value[1:])
+	}
+
+	return strings.TrimRight(string(value), "\x00")
+}
+
+// address formats a network address according to its IANA address family.
+func address(family byte, value []byte) string {
+	switch {
+	case family == addressFamilyIPv4 && len(value) == net.IPv4len:
+		return net.IP(value).String()
+	case family == addressFamilyIPv6 && len(value) == net.IPv6len:
+		return net.IP(value).String()
+	}
+
+	return fmt.Sprintf("%x", value)
+}
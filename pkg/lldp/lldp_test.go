@@ -0,0 +1,168 @@
+package lldp
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"runtime"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+// tlv encodes a TLV.
+func tlv(typ int, value ...byte) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(typ)<<9|uint16(len(value))), value...)
+}
+
+// frame builds an ethernet frame carrying the TLVs.
+func frame(tlvs ...[]byte) []byte {
+	b := append([]byte{}, Multicast...)
+	b = append(b, 0x02, 0, 0, 0, 0, 0x01, 0x88, 0xcc)
+	for _, t := range tlvs {
+		b = append(b, t...)
+	}
+
+	return append(b, tlv(tlvEnd)...)
+}
+
+var (
+	chassisTLV = tlv(tlvChassisID, chassisIDMACAddress, 0x02, 0, 0, 0, 0, 0x01)
+	portTLV    = tlv(tlvPortID, append([]byte{5}, "Ethernet1/1"...)...)
+	ttlTLV     = tlv(tlvTTL, 0, 120)
+)
+
+var _ = Describe("LLDP", func() {
+	Describe("Decode", func() {
+		It("should decode the neighbor", func() {
+			n, err := Decode(frame(
+				chassisTLV,
+				portTLV,
+				ttlTLV,
+				tlv(tlvSystemName, []byte("switch1")...),
+				tlv(tlvManagementAddress, 5, addressFamilyIPv4, 192, 0, 2, 1, 2, 0, 0, 0, 1, 0),
+			))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(Neighbor{
+				ChassisID:         "02:00:00:00:00:01",
+				PortID:            "Ethernet1/1",
+				SystemName:        "switch1",
+				ManagementAddress: "192.0.2.1",
+				TTL:               120 * time.Second,
+			}))
+			Expect(n.Attributes()).To(Equal(map[string]string{
+				"chassis_id":         "02:00:00:00:00:01",
+				"port_id":            "Ethernet1/1",
+				"system_name":        "switch1",
+				"management_address": "192.0.2.1",
+			}))
+		})
+
+		DescribeTable("should reject invalid frames",
+			func(f []byte, message string) {
+				_, err := Decode(f)
+				Expect(err).To(MatchError(message))
+			},
+			Entry("short frame", []byte{1, 2, 3}, "frame too short: 3 bytes"),
+			Entry("wrong ethertype", append(frame(chassisTLV, portTLV, ttlTLV)[:12], 0x08, 0x00), "unexpected ethertype 0x0800"),
+			Entry("missing ttl", frame(chassisTLV, portTLV), "missing mandatory tlvs"),
+			Entry("wrong order", frame(portTLV, chassisTLV, ttlTLV), "tlv 2 before tlv 1"),
+			Entry("optional before mandatory", frame(chassisTLV, tlv(tlvSystemName, 'a'), portTLV, ttlTLV), "tlv 5 before mandatory tlvs"),
+			Entry("truncated tlv", frame(chassisTLV, portTLV, ttlTLV)[:20], "tlv 1 is truncated"),
+		)
+	})
+
+	Describe("Listen", func() {
+		It("should receive frames injected on a veth", func() {
+			if os.Geteuid() != 0 {
+				Skip("creating network namespaces requires root")
+			}
+
+			var ns netns.NsHandle
+			func() {
+				runtime.LockOSThread()
+				defer runtime.UnlockOSThread()
+				origin, err := netns.Get()
+				Expect(err).NotTo(HaveOccurred())
+				defer origin.Close()
+
+				ns, err = netns.NewNamed("pf-status-relay-lldp")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netns.Set(origin)).To(Succeed())
+			}()
+			DeferCleanup(func() {
+				ns.Close()
+				Expect(netns.DeleteNamed("pf-status-relay-lldp")).To(Succeed())
+			})
+
+			h, err := netlink.NewHandleAt(ns)
+			Expect(err).NotTo(HaveOccurred())
+			defer h.Close()
+			Expect(h.LinkAdd(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "lldp0"}, PeerName: "lldp1"})).To(Succeed())
+			for _, name := range []string{"lldp0", "lldp1"} {
+				link, err := h.LinkByName(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(h.LinkSetUp(link)).To(Succeed())
+			}
+			peer, err := h.LinkByName("lldp1")
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			neighbors := make(chan Neighbor, 10)
+			errs := make(chan error, 1)
+			go func() {
+				// The thread is left in the namespace, so it is discarded when the goroutine exits.
+				runtime.LockOSThread()
+				if err := netns.Set(ns); err != nil {
+					errs <- err
+					return
+				}
+				errs <- Listen(ctx, "lldp0", func(n Neighbor) { neighbors <- n })
+			}()
+			DeferCleanup(func() {
+				cancel()
+				Eventually(errs, "2s").Should(Receive(BeNil()))
+			})
+
+			send := func(f []byte) {
+				runtime.LockOSThread()
+				defer runtime.UnlockOSThread()
+				origin, err := netns.Get()
+				Expect(err).NotTo(HaveOccurred())
+				defer origin.Close()
+				Expect(netns.Set(ns)).To(Succeed())
+				defer func() { Expect(netns.Set(origin)).To(Succeed()) }()
+
+				fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, 0)
+				Expect(err).NotTo(HaveOccurred())
+				defer unix.Close(fd)
+				Expect(unix.Sendto(fd, f, 0, &unix.SockaddrLinklayer{Ifindex: peer.Attrs().Index, Halen: 6})).To(Succeed())
+			}
+
+			// The listener may not be ready yet, so frames are sent until one is received.
+			f := frame(chassisTLV, portTLV, ttlTLV, tlv(tlvSystemName, []byte("switch1")...))
+			Eventually(func() []Neighbor {
+				send(f)
+				var received []Neighbor
+				for len(neighbors) > 0 {
+					received = append(received, <-neighbors)
+				}
+				return received
+			}, "2s", "100ms").Should(ContainElement(Neighbor{
+				ChassisID:  "02:00:00:00:00:01",
+				PortID:     "Ethernet1/1",
+				SystemName: "switch1",
+				TTL:        120 * time.Second,
+			}))
+
+			By("ignoring other ethertypes")
+			other := frame(chassisTLV, portTLV, ttlTLV, tlv(tlvSystemName, []byte("switch2")...))
+			send(append(other[:12:12], append([]byte{0x08, 0x00}, other[14:]...)...))
+			Consistently(neighbors, "600ms").ShouldNot(Receive(HaveField("SystemName", "switch2")))
+		})
+	})
+})
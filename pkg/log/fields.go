@@ -0,0 +1,99 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// aliases renames the attributes whose field name would be ambiguous on their own.
+var aliases = map[string]string{
+	"id":    "vf_id",
+	"state": "vf_state",
+}
+
+// field is an attribute of a record flattened for outputs with structured fields.
+type field struct {
+	key   string
+	value string
+}
+
+// fieldsHandler passes records along with their attributes flattened as fields to write. Nested attributes have
+// the keys of their groups as prefix, joined by underscores.
+type fieldsHandler struct {
+	write  func(r slog.Record, fields []field) error
+	fields []field
+	prefix string
+}
+
+func (h *fieldsHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *fieldsHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := append([]field(nil), h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = flatten(fields, h.prefix, a)
+		return true
+	})
+
+	return h.write(r, fields)
+}
+
+func (h *fieldsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.fields = append([]field(nil), h.fields...)
+	for _, a := range attrs {
+		c.fields = flatten(c.fields, h.prefix, a)
+	}
+
+	return &c
+}
+
+func (h *fieldsHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.prefix = h.prefix + name + "_"
+
+	return &c
+}
+
+// flatten appends attribute a to fields.
+func flatten(fields []field, prefix string, a slog.Attr) []field {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "_"
+		}
+		for _, a := range v.Group() {
+			fields = flatten(fields, prefix, a)
+		}
+		return fields
+	}
+	if a.Key == "" {
+		return fields
+	}
+
+	key := a.Key
+	if alias, ok := aliases[key]; ok && prefix == "" {
+		key = alias
+	}
+
+	return append(fields, field{key: prefix + key, value: v.String()})
+}
+
+// fieldName returns key in upper case with the characters that are not letters, digits or underscores replaced by
+// underscores, as expected by journald.
+func fieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, key)
+
+	// Fields starting with an underscore are trusted fields set by journald.
+	return strings.TrimLeft(name, "_")
+}
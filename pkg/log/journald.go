@@ -0,0 +1,62 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// journalSocket is the socket where journald receives entries with its native protocol.
+var journalSocket = "/run/systemd/journal/socket"
+
+// journal writes records to journald as entries whose fields are the attributes of the records.
+type journal struct {
+	conn *net.UnixConn
+}
+
+// newJournal returns a handler that writes to journald.
+func newJournal() (slog.Handler, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	j := &journal{conn: conn}
+
+	return &fieldsHandler{write: j.write}, nil
+}
+
+func (j *journal) write(r slog.Record, fields []field) error {
+	var b bytes.Buffer
+	appendJournalField(&b, "MESSAGE", r.Message)
+	appendJournalField(&b, "PRIORITY", strconv.Itoa(severity(r.Level)))
+	appendJournalField(&b, "SYSLOG_IDENTIFIER", identifier)
+	for _, f := range fields {
+		if name := fieldName(f.key); name != "" {
+			appendJournalField(&b, name, f.value)
+		}
+	}
+
+	_, err := j.conn.Write(b.Bytes())
+
+	return err
+}
+
+// appendJournalField appends a field to an entry. Values with newlines are prefixed with their size instead of
+// being terminated by a newline.
+func appendJournalField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
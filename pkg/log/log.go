@@ -1,8 +1,320 @@
 package log
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"log/syslog"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 )
 
-var Log = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+// Log is the logger of the application. Its level is the default level of the components.
+var Log = slog.New(&handler{out: newOutput(os.Stdout, "json")})
+
+// Loggers of the components whose level can be set apart.
+var (
+	Subscribe = component("subscribe")
+	Inspect   = component("inspect")
+	Monitor   = component("monitor")
+)
+
+// Components are the names of the components whose level can be set apart.
+var Components = []string{"subscribe", "inspect", "monitor"}
+
+// Config is the configuration of the logs.
+type Config struct {
+	// Level is the level of the logs: debug, info, warn or error.
+	Level string `yaml:"level"`
+	// Components maps components to their level when it differs from Level.
+	Components map[string]string `yaml:"components"`
+	// Format is the format of the logs: json, text or logfmt, which is an alias of text.
+	Format string `yaml:"format"`
+	// Output is where the logs are written: stdout, stderr, syslog, journald, the URL of a syslog server
+	// (udp://host:port, tcp://host:port or unix:///path), or the path of a file. Format does not apply to journald
+	// and syslog servers, which receive the attributes of the records as structured fields.
+	Output string `yaml:"output"`
+	// MaxSize is the size in megabytes at which the log file is rotated. It is not rotated when 0.
+	MaxSize int `yaml:"maxSize"`
+	// MaxBackups is the number of rotated log files that are kept.
+	MaxBackups int `yaml:"maxBackups"`
+}
+
+// Validate returns an error when the configuration is not valid.
+func (c Config) Validate() error {
+	_, _, err := c.levels()
+	if err != nil {
+		return err
+	}
+
+	switch c.Format {
+	case "json", "text", "logfmt":
+	default:
+		return fmt.Errorf("log format must be one of json, text or logfmt - current value: %s", c.Format)
+	}
+
+	if c.Output == "" {
+		return fmt.Errorf("log output must not be empty")
+	}
+
+	if strings.Contains(c.Output, "://") {
+		_, _, err := syslogServer(c.Output)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.MaxSize < 0 || c.MaxBackups < 0 {
+		return fmt.Errorf("log rotation must not be negative - current values: %d, %d", c.MaxSize, c.MaxBackups)
+	}
+
+	return nil
+}
+
+// levels returns the default level and the level of the components.
+func (c Config) levels() (slog.Level, map[string]slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.Level))
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid log level: %w", err)
+	}
+
+	components := make(map[string]slog.Level, len(c.Components))
+	for name, raw := range c.Components {
+		if !slices.Contains(Components, name) {
+			return 0, nil, fmt.Errorf("unknown log component %q", name)
+		}
+
+		var l slog.Level
+		err := l.UnmarshalText([]byte(raw))
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid log level of %s: %w", name, err)
+		}
+		components[name] = l
+	}
+
+	return level, components, nil
+}
+
+// Configure replaces Log with a logger that follows conf.
+func Configure(conf Config) error {
+	err := conf.Validate()
+	if err != nil {
+		return err
+	}
+
+	var out slog.Handler
+	switch conf.Output {
+	case "stdout":
+		out = newOutput(os.Stdout, conf.Format)
+	case "stderr":
+		out = newOutput(os.Stderr, conf.Format)
+	case "syslog":
+		w, err := syslog.New(syslog.LOG_DAEMON|syslog.LOG_INFO, "pf-status-relay")
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		s := &syslogWriter{w: w}
+		out = &syslogHandler{Handler: newOutput(s, conf.Format), w: s}
+	case "journald":
+		out, err = newJournal()
+		if err != nil {
+			return fmt.Errorf("failed to connect to journald: %w", err)
+		}
+	default:
+		if strings.Contains(conf.Output, "://") {
+			network, address, _ := syslogServer(conf.Output)
+			out, err = newRFC5424(network, address)
+			if err != nil {
+				return fmt.Errorf("failed to connect to syslog server: %w", err)
+			}
+			break
+		}
+
+		f, err := openRotatingFile(conf.Output, int64(conf.MaxSize)<<20, conf.MaxBackups)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		out = newOutput(f, conf.Format)
+	}
+	Log = slog.New(&handler{out: out})
+
+	level, components, _ := conf.levels()
+	SetLevels(level, components)
+
+	return nil
+}
+
+// syslogServer returns the network and the address of the syslog server with the given URL.
+func syslogServer(raw string) (string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid syslog server: %w", err)
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+		return u.Scheme, u.Host, nil
+	case "unix":
+		return u.Scheme, u.Path, nil
+	}
+
+	return "", "", fmt.Errorf("syslog server must use udp, tcp or unix - current value: %s", raw)
+}
+
+// newOutput returns a handler that writes every record to w in the given format. Levels are filtered by handler.
+func newOutput(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+
+	// The text handler of slog writes logfmt, for both text and logfmt.
+	return slog.NewTextHandler(w, opts)
+}
+
+var levels = struct {
+	sync.RWMutex
+	level      slog.Level
+	components map[string]slog.Level
+	debug      bool
+}{level: slog.LevelInfo}
+
+// SetLevels sets the default level and the level of the components that differ from it.
+func SetLevels(level slog.Level, components map[string]slog.Level) {
+	levels.Lock()
+	defer levels.Unlock()
+
+	levels.level, levels.components = level, components
+}
+
+// ToggleDebug enables debug logs for all components, or restores their levels when they are already enabled. It
+// returns true when debug logs are enabled.
+func ToggleDebug() bool {
+	levels.Lock()
+	defer levels.Unlock()
+
+	levels.debug = !levels.debug
+
+	return levels.debug
+}
+
+// level returns the level of a component, or the default level when component is empty.
+func level(component string) slog.Level {
+	levels.RLock()
+	defer levels.RUnlock()
+
+	if levels.debug {
+		return slog.LevelDebug
+	}
+	if l, ok := levels.components[component]; ok {
+		return l
+	}
+
+	return levels.level
+}
+
+// handler filters records by the level of their component. The records of components are passed to the output of
+// Log at the time they are logged, so that they follow Log when it is replaced.
+type handler struct {
+	component string
+	out       slog.Handler
+	// with adds the attributes and groups of the logger to the output.
+	with []func(slog.Handler) slog.Handler
+}
+
+// component returns the logger of a component.
+func component(name string) *slog.Logger {
+	return slog.New(&handler{component: name})
+}
+
+func (h *handler) output() slog.Handler {
+	out := h.out
+	if out == nil {
+		out = Log.Handler()
+		if root, ok := out.(*handler); ok {
+			out = root.output()
+		}
+	}
+	for _, with := range h.with {
+		out = with(out)
+	}
+
+	return out
+}
+
+func (h *handler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= level(h.component) && h.output().Enabled(ctx, l)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	return h.output().Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.add(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.add(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+func (h *handler) add(with func(slog.Handler) slog.Handler) slog.Handler {
+	c := *h
+	c.with = append(append([]func(slog.Handler) slog.Handler(nil), h.with...), with)
+
+	return &c
+}
+
+// syslogWriter writes the records to syslog with the severity of their level.
+type syslogWriter struct {
+	mu    sync.Mutex
+	level slog.Level
+	w     *syslog.Writer
+}
+
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	var err error
+	switch {
+	case s.level >= slog.LevelError:
+		err = s.w.Err(string(p))
+	case s.level >= slog.LevelWarn:
+		err = s.w.Warning(string(p))
+	case s.level >= slog.LevelInfo:
+		err = s.w.Info(string(p))
+	default:
+		err = s.w.Debug(string(p))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// syslogHandler passes the level of the records to the syslog writer of its handler.
+type syslogHandler struct {
+	slog.Handler
+	w *syslogWriter
+}
+
+func (s *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	s.w.mu.Lock()
+	defer s.w.mu.Unlock()
+
+	s.w.level = r.Level
+
+	return s.Handler.Handle(ctx, r)
+}
+
+func (s *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{Handler: s.Handler.WithAttrs(attrs), w: s.w}
+}
+
+func (s *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{Handler: s.Handler.WithGroup(name), w: s.w}
+}
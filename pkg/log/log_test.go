@@ -0,0 +1,118 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Log", func() {
+	var (
+		buf            bytes.Buffer
+		originalLogger *slog.Logger
+	)
+
+	BeforeEach(func() {
+		buf.Reset()
+		originalLogger = Log
+		Log = slog.New(&handler{out: newOutput(&buf, "logfmt")})
+	})
+
+	AfterEach(func() {
+		Log = originalLogger
+		SetLevels(slog.LevelInfo, nil)
+		if ToggleDebug() {
+			ToggleDebug()
+		}
+	})
+
+	It("should filter the logs of components by their level", func() {
+		SetLevels(slog.LevelWarn, map[string]slog.Level{"monitor": slog.LevelDebug})
+
+		Log.Info("dropped")
+		Inspect.Info("dropped")
+		Monitor.Debug("kept", "interface", "test")
+		Log.Warn("kept")
+
+		Expect(buf.String()).NotTo(ContainSubstring("dropped"))
+		Expect(strings.Count(buf.String(), "msg=kept")).To(Equal(2))
+		Expect(buf.String()).To(ContainSubstring("level=DEBUG msg=kept interface=test"))
+	})
+
+	It("should write the logs of components to the current logger", func() {
+		var other bytes.Buffer
+		Log = slog.New(slog.NewJSONHandler(&other, nil))
+
+		Subscribe.With("index", 1).Info("event received")
+		Subscribe.Debug("dropped")
+
+		Expect(buf.String()).To(BeEmpty())
+		Expect(other.String()).To(ContainSubstring(`"msg":"event received","index":1`))
+		Expect(other.String()).NotTo(ContainSubstring("dropped"))
+	})
+
+	It("should toggle debug logs", func() {
+		Expect(ToggleDebug()).To(BeTrue())
+		Monitor.Debug("kept")
+		Expect(ToggleDebug()).To(BeFalse())
+		Monitor.Debug("dropped")
+
+		Expect(buf.String()).To(ContainSubstring("msg=kept"))
+		Expect(buf.String()).NotTo(ContainSubstring("dropped"))
+	})
+
+	Context("Configure", func() {
+		It("should write to a file that is rotated", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "relay.log")
+			Expect(Configure(Config{Level: "debug", Format: "json", Output: path, MaxBackups: 2})).To(Succeed())
+
+			Log.Debug("written")
+			raw, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(raw)).To(ContainSubstring(`"level":"DEBUG","msg":"written"`))
+		})
+
+		It("should return an error when the format is not valid", func() {
+			Expect(Configure(Config{Level: "info", Format: "xml", Output: "stdout"})).To(MatchError("log format must be one of json, text or logfmt - current value: xml"))
+		})
+	})
+
+	Context("rotatingFile", func() {
+		It("should keep the given number of backups", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "relay.log")
+			f, err := openRotatingFile(path, 10, 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+				_, err = f.Write([]byte(line))
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			for file, content := range map[string]string{path: "fourth\n", path + ".1": "third\n", path + ".2": "second\n"} {
+				raw, err := os.ReadFile(file)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(raw)).To(Equal(content))
+			}
+			Expect(path + ".3").NotTo(BeAnExistingFile())
+		})
+
+		It("should append to an existing file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "relay.log")
+			Expect(os.WriteFile(path, []byte("old\n"), 0o644)).To(Succeed())
+
+			f, err := openRotatingFile(path, 0, 0)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.Write([]byte("new\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			raw, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(raw)).To(Equal("old\nnew\n"))
+		})
+	})
+})
@@ -0,0 +1,154 @@
+package log
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// identifier is the application name of the records sent to journald and syslog.
+const identifier = "pf-status-relay"
+
+// structuredDataID is the id of the structured data element holding the attributes of the records. 32473 is the
+// private enterprise number reserved for documentation by RFC 5612.
+const structuredDataID = identifier + "@32473"
+
+// facilityDaemon is the syslog facility of the records.
+const facilityDaemon = 3
+
+// severity returns the syslog severity of a level.
+func severity(l slog.Level) int {
+	switch {
+	case l >= slog.LevelError:
+		return 3
+	case l >= slog.LevelWarn:
+		return 4
+	case l >= slog.LevelInfo:
+		return 6
+	}
+
+	return 7
+}
+
+// rfc5424 sends records to a syslog server as RFC 5424 messages whose structured data are the attributes of the
+// records. Messages are framed by their length over TCP, as described by RFC 6587.
+type rfc5424 struct {
+	network  string
+	address  string
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// newRFC5424 returns a handler that sends records to the syslog server at address, over udp, tcp or unix.
+func newRFC5424(network, address string) (slog.Handler, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	if network == "unix" {
+		network = "unixgram"
+	}
+
+	s := &rfc5424{network: network, address: address, hostname: hostname}
+	err = s.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fieldsHandler{write: s.write}, nil
+}
+
+func (s *rfc5424) write(r slog.Record, fields []field) error {
+	msg := s.format(r, fields)
+	if s.network == "tcp" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The connection is dialed again on every write until the server is back.
+	if s.conn == nil {
+		err := s.dial()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.send(msg)
+	if err == nil || s.network != "tcp" {
+		return err
+	}
+
+	// Connect again once when the server closed the connection.
+	err = s.dial()
+	if err != nil {
+		return err
+	}
+
+	return s.send(msg)
+}
+
+// send writes msg to the connection, which is closed on failure so that the next write dials again.
+func (s *rfc5424) send(msg string) error {
+	_, err := s.conn.Write([]byte(msg))
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	return err
+}
+
+// dial connects to the server. The connection is nil when it fails.
+func (s *rfc5424) dial() error {
+	conn, err := net.Dial(s.network, s.address)
+	if err != nil {
+		s.conn = nil
+		return err
+	}
+	s.conn = conn
+
+	return nil
+}
+
+// format returns the RFC 5424 message of a record.
+func (s *rfc5424) format(r slog.Record, fields []field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d - ", facilityDaemon*8+severity(r.Level), r.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"), s.hostname, identifier, os.Getpid())
+
+	if len(fields) == 0 {
+		b.WriteString("-")
+	} else {
+		b.WriteString("[" + structuredDataID)
+		for _, f := range fields {
+			fmt.Fprintf(&b, ` %s="%s"`, paramName(f.key), paramValue.Replace(f.value))
+		}
+		b.WriteString("]")
+	}
+
+	b.WriteString(" " + r.Message)
+
+	return b.String()
+}
+
+// paramName returns key with the characters that are not allowed in the names of parameters replaced by
+// underscores, truncated to 32 characters.
+func paramName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, key)
+
+	return name[:min(len(name), 32)]
+}
+
+// paramValue escapes the characters that are not allowed in the values of parameters.
+var paramValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
@@ -0,0 +1,176 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// listenUnixgram returns a datagram socket standing in for journald or syslog.
+func listenUnixgram() (*net.UnixConn, string) {
+	path := filepath.Join(GinkgoT().TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(conn.Close)
+
+	return conn, path
+}
+
+// receive returns the next datagram received by conn.
+func receive(conn *net.UnixConn) string {
+	Expect(conn.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+	buf := make([]byte, 65536)
+	n, err := conn.Read(buf)
+	Expect(err).NotTo(HaveOccurred())
+
+	return string(buf[:n])
+}
+
+var _ = Describe("Structured outputs", func() {
+	var originalLogger *slog.Logger
+
+	BeforeEach(func() {
+		originalLogger = Log
+	})
+
+	AfterEach(func() {
+		Log = originalLogger
+		SetLevels(slog.LevelInfo, nil)
+	})
+
+	Context("journald", func() {
+		It("should write entries with the attributes as fields", func() {
+			conn, path := listenUnixgram()
+			original := journalSocket
+			journalSocket = path
+			DeferCleanup(func() { journalSocket = original })
+
+			Expect(Configure(Config{Level: "info", Format: "json", Output: "journald"})).To(Succeed())
+
+			Log.Info("vf link state was set", "id", 3, "state", "disable", "interface", "ens6f0np0")
+			Expect(receive(conn)).To(Equal("MESSAGE=vf link state was set\nPRIORITY=6\nSYSLOG_IDENTIFIER=pf-status-relay\n" +
+				"VF_ID=3\nVF_STATE=disable\nINTERFACE=ens6f0np0\n"))
+
+			Monitor.With("interface", "ens6f0np0").WithGroup("status").Warn("lacp is down", "lacp_state", "down", "error", errors.New("line\nbreak"))
+			entry := receive(conn)
+			Expect(entry).To(HavePrefix("MESSAGE=lacp is down\nPRIORITY=4\nSYSLOG_IDENTIFIER=pf-status-relay\nINTERFACE=ens6f0np0\nSTATUS_LACP_STATE=down\nSTATUS_ERROR\n"))
+
+			size := make([]byte, 8)
+			binary.LittleEndian.PutUint64(size, uint64(len("line\nbreak")))
+			Expect(entry).To(HaveSuffix("STATUS_ERROR\n" + string(size) + "line\nbreak\n"))
+		})
+	})
+
+	Context("RFC 5424", func() {
+		It("should send messages with the attributes as structured data over unix", func() {
+			conn, path := listenUnixgram()
+			Expect(Configure(Config{Level: "debug", Format: "json", Output: "unix://" + path})).To(Succeed())
+
+			Monitor.Debug("vf info", "id", 0, "reason", `lacp "down"]`)
+			msg := receive(conn)
+			Expect(msg).To(MatchRegexp(`^<31>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}Z \S+ pf-status-relay \d+ - `))
+			Expect(msg).To(HaveSuffix(` - [pf-status-relay@32473 vf_id="0" reason="lacp \"down\"\]"] vf info`))
+
+			Log.Error("no attributes")
+			Expect(receive(conn)).To(HavePrefix("<27>1 "))
+		})
+
+		It("should frame messages by their length over tcp", func() {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(l.Close)
+
+			Expect(Configure(Config{Level: "info", Format: "json", Output: "tcp://" + l.Addr().String()})).To(Succeed())
+			server, err := l.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(server.Close)
+
+			Log.Info("first", "interface", "test")
+			Log.Info("second")
+
+			r := bufio.NewReader(server)
+			for _, want := range []string{`[pf-status-relay@32473 interface="test"] first`, "- second"} {
+				raw, err := r.ReadString(' ')
+				Expect(err).NotTo(HaveOccurred())
+				length, err := strconv.Atoi(strings.TrimSpace(raw))
+				Expect(err).NotTo(HaveOccurred())
+
+				msg := make([]byte, length)
+				_, err = io.ReadFull(r, msg)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(msg)).To(HaveSuffix(want))
+			}
+		})
+
+		It("should connect again on the next write when the server is back over unix", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "socket")
+			listen := func() *net.UnixConn {
+				conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+				Expect(err).NotTo(HaveOccurred())
+				return conn
+			}
+			server := listen()
+
+			s := &rfc5424{network: "unixgram", address: path, hostname: "test"}
+			Expect(s.dial()).To(Succeed())
+			Expect(s.write(slog.NewRecord(time.Now(), slog.LevelInfo, "first", 0), nil)).To(Succeed())
+			Expect(receive(server)).To(HaveSuffix("- first"))
+
+			By("restarting the server")
+			Expect(server.Close()).To(Succeed())
+			Expect(os.Remove(path)).To(Succeed())
+			Expect(s.write(slog.NewRecord(time.Now(), slog.LevelInfo, "lost", 0), nil)).NotTo(Succeed())
+			Expect(s.conn).To(BeNil())
+
+			server = listen()
+			DeferCleanup(server.Close)
+			Expect(s.write(slog.NewRecord(time.Now(), slog.LevelInfo, "second", 0), nil)).To(Succeed())
+			DeferCleanup(s.conn.Close)
+			Expect(receive(server)).To(HaveSuffix("- second"))
+		})
+
+		It("should connect again on the next write when the server is back over tcp", func() {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			address := l.Addr().String()
+			Expect(l.Close()).To(Succeed())
+
+			s := &rfc5424{network: "tcp", address: address, hostname: "test"}
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, "lost", 0)
+			Expect(s.write(r, nil)).NotTo(Succeed())
+			Expect(s.conn).To(BeNil())
+
+			l, err = net.Listen("tcp", address)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(l.Close)
+
+			r = slog.NewRecord(time.Now(), slog.LevelInfo, "sent", 0)
+			Expect(s.write(r, nil)).To(Succeed())
+			DeferCleanup(s.conn.Close)
+			server, err := l.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(server.Close)
+
+			Expect(server.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+			buf := make([]byte, 1024)
+			n, err := server.Read(buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(buf[:n])).To(HaveSuffix("- sent"))
+		})
+
+		It("should return an error for an unknown network", func() {
+			Expect(Configure(Config{Level: "info", Format: "json", Output: "http://localhost"})).To(MatchError("syslog server must use udp, tcp or unix - current value: http://localhost"))
+		})
+	})
+})
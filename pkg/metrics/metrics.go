@@ -0,0 +1,275 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric is a family of samples written in the Prometheus text format.
+type Metric interface {
+	// Name returns the name of the metric.
+	Name() string
+	// Write writes the help, the type and the samples of the metric to w.
+	Write(w io.Writer)
+}
+
+// Registry holds the metrics that are exposed.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]Metric
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]Metric)}
+}
+
+// Register adds m to the registry, replacing the metric with the same name.
+func (r *Registry) Register(m Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics[m.Name()] = m
+}
+
+// Write writes all metrics sorted by name to w.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		r.metrics[name].Write(w)
+	}
+}
+
+// Handler returns an HTTP handler that exposes the metrics of r.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// desc describes a metric.
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) Name() string {
+	return d.name
+}
+
+func (d *desc) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.kind)
+}
+
+// sample writes a sample of the metric with the given suffix, label values and extra label.
+func (d *desc) sample(w io.Writer, suffix string, values []string, extra string, value float64) {
+	var pairs []string
+	for i, l := range d.labels {
+		pairs = append(pairs, l+`="`+escape.Replace(values[i])+`"`)
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+
+	labels := ""
+	if len(pairs) > 0 {
+		labels = "{" + strings.Join(pairs, ",") + "}"
+	}
+	fmt.Fprintf(w, "%s%s%s %s\n", d.name, suffix, labels, format(value))
+}
+
+var escape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func format(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// key joins label values into a map key.
+func key(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// series holds the samples of a metric by label values.
+type series[T any] struct {
+	mu     sync.Mutex
+	keys   []string
+	values map[string][]string
+	data   map[string]*T
+}
+
+// get returns the data of the series with the given label values, creating it with init when it does not exist.
+func (s *series[T]) get(values []string, init func() *T) *T {
+	k := key(values)
+	if d, ok := s.data[k]; ok {
+		return d
+	}
+
+	if s.data == nil {
+		s.data, s.values = make(map[string]*T), make(map[string][]string)
+	}
+	d := init()
+	s.keys = append(s.keys, k)
+	s.values[k] = slices.Clone(values)
+	s.data[k] = d
+
+	return d
+}
+
+// each calls f for every series sorted by label values.
+func (s *series[T]) each(f func(values []string, d *T)) {
+	keys := slices.Clone(s.keys)
+	slices.Sort(keys)
+	for _, k := range keys {
+		f(s.values[k], s.data[k])
+	}
+}
+
+// Counter is a counter partitioned by labels.
+type Counter struct {
+	desc
+	series series[float64]
+}
+
+// NewCounter returns a counter with the given labels.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{desc: desc{name: name, help: help, kind: "counter", labels: labels}}
+}
+
+// Inc increments the counter with the given label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v to the counter with the given label values.
+func (c *Counter) Add(v float64, values ...string) {
+	c.series.mu.Lock()
+	defer c.series.mu.Unlock()
+
+	*c.series.get(values, func() *float64 { return new(float64) }) += v
+}
+
+// Value returns the value of the counter with the given label values.
+func (c *Counter) Value(values ...string) float64 {
+	c.series.mu.Lock()
+	defer c.series.mu.Unlock()
+
+	if v, ok := c.series.data[key(values)]; ok {
+		return *v
+	}
+
+	return 0
+}
+
+func (c *Counter) Write(w io.Writer) {
+	c.series.mu.Lock()
+	defer c.series.mu.Unlock()
+
+	c.header(w)
+	c.series.each(func(values []string, v *float64) {
+		c.sample(w, "", values, "", *v)
+	})
+}
+
+// Histogram counts observations in buckets, partitioned by labels.
+type Histogram struct {
+	desc
+	buckets []float64
+	series  series[histogram]
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram returns a histogram with the given upper bounds of buckets, sorted in increasing order, and labels.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{desc: desc{name: name, help: help, kind: "histogram", labels: labels}, buckets: buckets}
+}
+
+// Observe adds an observation to the histogram with the given label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.series.mu.Lock()
+	defer h.series.mu.Unlock()
+
+	s := h.series.get(values, func() *histogram {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	})
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// Count returns the number of observations of the histogram with the given label values.
+func (h *Histogram) Count(values ...string) uint64 {
+	h.series.mu.Lock()
+	defer h.series.mu.Unlock()
+
+	if s, ok := h.series.data[key(values)]; ok {
+		return s.count
+	}
+
+	return 0
+}
+
+func (h *Histogram) Write(w io.Writer) {
+	h.series.mu.Lock()
+	defer h.series.mu.Unlock()
+
+	h.header(w)
+	h.series.each(func(values []string, s *histogram) {
+		for i, upper := range h.buckets {
+			h.sample(w, "_bucket", values, `le="`+format(upper)+`"`, float64(s.counts[i]))
+		}
+		h.sample(w, "_bucket", values, `le="+Inf"`, float64(s.count))
+		h.sample(w, "_sum", values, "", s.sum)
+		h.sample(w, "_count", values, "", float64(s.count))
+	})
+}
+
+// Gauge is a gauge whose samples are collected when the metrics are written.
+type Gauge struct {
+	desc
+	collect func(emit func(v float64, values ...string))
+}
+
+// NewGauge returns a gauge with the given labels whose samples are emitted by collect.
+func NewGauge(name, help string, collect func(emit func(v float64, values ...string)), labels ...string) *Gauge {
+	return &Gauge{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, collect: collect}
+}
+
+func (g *Gauge) Write(w io.Writer) {
+	g.header(w)
+	g.collect(func(v float64, values ...string) {
+		g.sample(w, "", values, "", v)
+	})
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	var r *Registry
+
+	BeforeEach(func() {
+		r = NewRegistry()
+	})
+
+	write := func() string {
+		var b bytes.Buffer
+		r.Write(&b)
+		return b.String()
+	}
+
+	It("should write counters sorted by label values", func() {
+		c := NewCounter("test_total", "Test counter.", "interface", "reason")
+		r.Register(c)
+		c.Inc("pf1", `lacp "down"`)
+		c.Add(2, "pf0", "")
+
+		Expect(c.Value("pf0", "")).To(Equal(2.0))
+		Expect(write()).To(Equal(`# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{interface="pf0",reason=""} 2
+test_total{interface="pf1",reason="lacp \"down\""} 1
+`))
+	})
+
+	It("should write histograms with cumulative buckets", func() {
+		h := NewHistogram("test_seconds", "Test histogram.", []float64{0.1, 1}, "interface")
+		r.Register(h)
+		h.Observe(0.05, "pf0")
+		h.Observe(0.5, "pf0")
+		h.Observe(2, "pf0")
+
+		Expect(h.Count("pf0")).To(Equal(uint64(3)))
+		Expect(write()).To(Equal(`# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{interface="pf0",le="0.1"} 1
+test_seconds_bucket{interface="pf0",le="1"} 2
+test_seconds_bucket{interface="pf0",le="+Inf"} 3
+test_seconds_sum{interface="pf0"} 2.55
+test_seconds_count{interface="pf0"} 3
+`))
+	})
+
+	It("should collect gauges when written and sort metrics by name", func() {
+		depth := 0
+		r.Register(NewGauge("test_depth", "Test gauge.", func(emit func(float64, ...string)) {
+			emit(float64(depth))
+		}))
+		r.Register(NewCounter("a_total", "First."))
+
+		depth = 3
+		Expect(write()).To(Equal(`# HELP a_total First.
+# TYPE a_total counter
+# HELP test_depth Test gauge.
+# TYPE test_depth gauge
+test_depth 3
+`))
+	})
+
+	It("should serve the metrics", func() {
+		c := NewCounter("test_total", "Test counter.")
+		r.Register(c)
+		c.Inc()
+
+		w := httptest.NewRecorder()
+		r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(w.Header().Get("Content-Type")).To(HavePrefix("text/plain; version=0.0.4"))
+		body, err := io.ReadAll(w.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("test_total 1\n"))
+	})
+})
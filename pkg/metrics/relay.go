@@ -0,0 +1,29 @@
+package metrics
+
+// DefaultRegistry is the registry of the metrics of the relay.
+var DefaultRegistry = NewRegistry()
+
+// Metrics of the relay that are updated as events happen. The metrics describing the state of the PFs are
+// collected by their owners when the metrics are written.
+var (
+	Transitions = NewCounter("pf_status_relay_lacp_transitions_total",
+		"Number of transitions of the LACP state of PFs.", "interface", "state", "detector")
+	ActuationDuration = NewHistogram("pf_status_relay_actuation_duration_seconds",
+		"Time to relay a failure of a PF, or a recovery from it, to all of its VFs.",
+		[]float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, "interface")
+	ActuationFailures = NewCounter("pf_status_relay_actuation_failures_total",
+		"Number of times relaying the health of a PF to its VFs failed.", "interface")
+	NetlinkDuration = NewHistogram("pf_status_relay_netlink_request_duration_seconds",
+		"Duration of netlink requests.",
+		[]float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.05, 0.1, 1}, "method")
+	NetlinkErrors = NewCounter("pf_status_relay_netlink_errors_total",
+		"Number of netlink requests that failed.", "method")
+	SubscriptionReconnects = NewCounter("pf_status_relay_subscription_reconnects_total",
+		"Number of times the subscription to link changes was restored after it failed.")
+)
+
+func init() {
+	for _, m := range []Metric{Transitions, ActuationDuration, ActuationFailures, NetlinkDuration, NetlinkErrors, SubscriptionReconnects} {
+		DefaultRegistry.Register(m)
+	}
+}
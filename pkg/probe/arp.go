@@ -0,0 +1,124 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const arpPacketLen = 28
+
+// ARP probes targets with ARP requests.
+type ARP struct{}
+
+// Probe sends an ARP request for target and waits for the reply.
+func (*ARP) Probe(iface string, target net.IP, timeout time.Duration) error {
+	target = target.To4()
+	if target == nil {
+		return fmt.Errorf("arp probe requires an IPv4 target")
+	}
+
+	i, ip, err := source(iface)
+	if err != nil {
+		return err
+	}
+	if len(i.HardwareAddr) != 6 {
+		return fmt.Errorf("interface %s has no ethernet address", iface)
+	}
+
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, int(htons(unix.ETH_P_ARP)))
+	if err != nil {
+		return fmt.Errorf("failed to open packet socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	err = unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_ARP), Ifindex: i.Index})
+	if err != nil {
+		return fmt.Errorf("failed to bind packet socket to %s: %w", iface, err)
+	}
+
+	request := make([]byte, arpPacketLen)
+	binary.BigEndian.PutUint16(request[0:], 1) // Ethernet.
+	binary.BigEndian.PutUint16(request[2:], unix.ETH_P_IP)
+	request[4], request[5] = 6, 4
+	binary.BigEndian.PutUint16(request[6:], 1) // Request.
+	copy(request[8:], i.HardwareAddr)
+	copy(request[14:], ip)
+	copy(request[24:], target)
+
+	broadcast := &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_ARP), Ifindex: i.Index, Halen: 6}
+	copy(broadcast.Addr[:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	err = unix.Sendto(fd, request, 0, broadcast)
+	if err != nil {
+		return fmt.Errorf("failed to send arp request: %w", err)
+	}
+
+	buf := make([]byte, 128)
+	deadline := time.Now().Add(timeout)
+	for {
+		n, err := recvUntil(fd, buf, deadline)
+		if err != nil {
+			return err
+		}
+
+		reply := buf[:n]
+		if len(reply) < arpPacketLen {
+			continue
+		}
+		// Only accept replies from the target to our address.
+		if binary.BigEndian.Uint16(reply[6:]) == 2 && bytes.Equal(reply[14:18], target) && bytes.Equal(reply[18:24], i.HardwareAddr) {
+			return nil
+		}
+	}
+}
+
+// recvUntil reads a packet from fd, failing when deadline is reached.
+func recvUntil(fd int, buf []byte, deadline time.Time) (int, error) {
+	for {
+		tv, ok := receiveTimeout(time.Until(deadline))
+		if !ok {
+			return 0, errTimeout
+		}
+
+		err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv)
+		if err != nil {
+			return 0, err
+		}
+
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		return n, nil
+	}
+}
+
+var errTimeout = errors.New("timeout waiting for reply")
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+// receiveTimeout returns the SO_RCVTIMEO value for the remaining time, which is false when less than a microsecond
+// remains. The timeval is never zero, which would block forever.
+func receiveTimeout(remaining time.Duration) (unix.Timeval, bool) {
+	if remaining < time.Microsecond {
+		return unix.Timeval{}, false
+	}
+
+	tv := unix.NsecToTimeval(remaining.Nanoseconds())
+	if tv.Sec == 0 && tv.Usec == 0 {
+		tv.Usec = 1
+	}
+
+	return tv, true
+}
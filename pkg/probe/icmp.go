@@ -0,0 +1,103 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	icmpEchoReply   = 0
+	icmpEchoRequest = 8
+)
+
+// ICMP probes targets with ICMP echo requests.
+type ICMP struct {
+	id  uint16
+	seq atomic.Uint32
+}
+
+// NewICMP returns an ICMP prober.
+func NewICMP() *ICMP {
+	return &ICMP{id: uint16(os.Getpid())}
+}
+
+// Probe sends an ICMP echo request to target through the interface and waits for the reply.
+func (p *ICMP) Probe(iface string, target net.IP, timeout time.Duration) error {
+	target = target.To4()
+	if target == nil {
+		return fmt.Errorf("icmp probe requires an IPv4 target")
+	}
+
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.IPPROTO_ICMP)
+	if err != nil {
+		return fmt.Errorf("failed to open icmp socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	err = unix.BindToDevice(fd, iface)
+	if err != nil {
+		return fmt.Errorf("failed to bind icmp socket to %s: %w", iface, err)
+	}
+
+	seq := uint16(p.seq.Add(1))
+	request := make([]byte, 16)
+	request[0] = icmpEchoRequest
+	binary.BigEndian.PutUint16(request[4:], p.id)
+	binary.BigEndian.PutUint16(request[6:], seq)
+	binary.BigEndian.PutUint64(request[8:], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint16(request[2:], checksum(request))
+
+	dst := &unix.SockaddrInet4{}
+	copy(dst.Addr[:], target)
+	err = unix.Sendto(fd, request, 0, dst)
+	if err != nil {
+		return fmt.Errorf("failed to send icmp echo request: %w", err)
+	}
+
+	buf := make([]byte, 1500)
+	deadline := time.Now().Add(timeout)
+	for {
+		n, err := recvUntil(fd, buf, deadline)
+		if err != nil {
+			return err
+		}
+
+		// Raw sockets return the IP header.
+		packet := buf[:n]
+		if len(packet) < 20 {
+			continue
+		}
+		ihl := int(packet[0]&0x0f) * 4
+		if len(packet) < ihl+8 || !bytes.Equal(packet[12:16], target) {
+			continue
+		}
+
+		reply := packet[ihl:]
+		if reply[0] == icmpEchoReply && binary.BigEndian.Uint16(reply[4:]) == p.id && binary.BigEndian.Uint16(reply[6:]) == seq {
+			return nil
+		}
+	}
+}
+
+// checksum returns the internet checksum of b.
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+
+	return ^uint16(sum)
+}
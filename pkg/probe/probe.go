@@ -0,0 +1,48 @@
+package probe
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Prober verifies that a target is reachable through an interface.
+type Prober interface {
+	// Probe returns an error when target does not answer through the interface named iface before timeout.
+	Probe(iface string, target net.IP, timeout time.Duration) error
+}
+
+// New returns the prober that implements method, which is either "arp" or "icmp".
+func New(method string) (Prober, error) {
+	switch method {
+	case "arp":
+		return &ARP{}, nil
+	case "icmp":
+		return NewICMP(), nil
+	default:
+		return nil, fmt.Errorf("unknown probe method %q", method)
+	}
+}
+
+// source returns the hardware address and the first IPv4 address of the interface.
+func source(iface string) (*net.Interface, net.IP, error) {
+	i, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addrs, err := i.Addrs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok {
+			if ip := n.IP.To4(); ip != nil {
+				return i, ip, nil
+			}
+		}
+	}
+
+	return i, net.IPv4zero.To4(), nil
+}
@@ -0,0 +1,125 @@
+package probe
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+// inNetns runs f in the network namespace ns, or in the current one if ns is not valid, and restores the
+// namespace of the thread afterwards. Ginkgo runs every node in its own goroutine, so namespaces are switched
+// within each node.
+func inNetns(ns netns.NsHandle, f func()) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	origin, err := netns.Get()
+	Expect(err).NotTo(HaveOccurred())
+	defer origin.Close()
+
+	if ns.IsOpen() {
+		Expect(netns.Set(ns)).To(Succeed())
+	}
+	defer func() {
+		Expect(netns.Set(origin)).To(Succeed())
+	}()
+
+	f()
+}
+
+var _ = Describe("Probe", func() {
+	var local netns.NsHandle
+
+	// The probes run in a network namespace connected through a veth pair to a peer namespace that answers.
+	BeforeEach(func() {
+		if os.Geteuid() != 0 {
+			Skip("creating network namespaces requires root")
+		}
+
+		// Creating a namespace switches the current thread to it.
+		var peer netns.NsHandle
+		inNetns(netns.None(), func() {
+			var err error
+			peer, err = netns.NewNamed("pf-status-relay-peer")
+			Expect(err).NotTo(HaveOccurred())
+
+			local, err = netns.NewNamed("pf-status-relay-local")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		DeferCleanup(func() {
+			peer.Close()
+			local.Close()
+			Expect(netns.DeleteNamed("pf-status-relay-peer")).To(Succeed())
+			Expect(netns.DeleteNamed("pf-status-relay-local")).To(Succeed())
+		})
+
+		setup := func(ns netns.NsHandle, name, addr string) {
+			h, err := netlink.NewHandleAt(ns)
+			Expect(err).NotTo(HaveOccurred())
+			defer h.Close()
+
+			link, err := h.LinkByName(name)
+			Expect(err).NotTo(HaveOccurred())
+			a, err := netlink.ParseAddr(addr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(h.AddrAdd(link, a)).To(Succeed())
+			Expect(h.LinkSetUp(link)).To(Succeed())
+		}
+
+		h, err := netlink.NewHandleAt(local)
+		Expect(err).NotTo(HaveOccurred())
+		defer h.Close()
+
+		err = h.LinkAdd(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "probe0"}, PeerName: "probe1", PeerNamespace: netlink.NsFd(peer)})
+		Expect(err).NotTo(HaveOccurred())
+
+		setup(peer, "probe1", "192.0.2.2/24")
+		setup(local, "probe0", "192.0.2.1/24")
+	})
+
+	for _, method := range []string{"arp", "icmp"} {
+		Context(method, func() {
+			It("should succeed when the target answers", func() {
+				p, err := New(method)
+				Expect(err).NotTo(HaveOccurred())
+
+				inNetns(local, func() {
+					Eventually(func() error {
+						return p.Probe("probe0", net.ParseIP("192.0.2.2"), 200*time.Millisecond)
+					}, "3s", "100ms").Should(Succeed())
+				})
+			})
+
+			It("should fail when the target does not answer", func() {
+				p, err := New(method)
+				Expect(err).NotTo(HaveOccurred())
+
+				inNetns(local, func() {
+					err = p.Probe("probe0", net.ParseIP("192.0.2.3"), 200*time.Millisecond)
+					Expect(err).To(MatchError(errTimeout))
+				})
+			})
+		})
+	}
+})
+
+var _ = Describe("receiveTimeout", func() {
+	DescribeTable("conversion",
+		func(remaining time.Duration, tv unix.Timeval, ok bool) {
+			got, gotOK := receiveTimeout(remaining)
+			Expect(gotOK).To(Equal(ok))
+			Expect(got).To(Equal(tv))
+		},
+		Entry("expired", time.Duration(0), unix.Timeval{}, false),
+		Entry("less than a microsecond", 999*time.Nanosecond, unix.Timeval{}, false),
+		Entry("rounded up to a microsecond", 1001*time.Nanosecond, unix.Timeval{Usec: 2}, true),
+		Entry("seconds", 1500*time.Millisecond, unix.Timeval{Sec: 1, Usec: 500000}, true),
+	)
+})
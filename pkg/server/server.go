@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/openshift/pf-status-relay/pkg/log"
+)
+
+// shutdownTimeout is the time given to the requests in progress to complete when the server stops.
+const shutdownTimeout = 5 * time.Second
+
+// Start serves handler on address until ctx is done. It returns an error when address cannot be listened on.
+func Start(ctx context.Context, address string, handler http.Handler, wg *sync.WaitGroup) error {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	serve(ctx, l, handler, wg)
+
+	return nil
+}
+
+// StartUnix serves handler on a unix socket at path until ctx is done, replacing the socket left by a previous
+// instance. The socket is only accessible by its owner, and is removed when the server stops.
+func StartUnix(ctx context.Context, path string, handler http.Handler, wg *sync.WaitGroup) error {
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(path, 0o600)
+	if err != nil {
+		_ = l.Close()
+		return err
+	}
+
+	serve(ctx, l, handler, wg)
+
+	return nil
+}
+
+// serve serves handler on l until ctx is done.
+func serve(ctx context.Context, l net.Listener, handler http.Handler, wg *sync.WaitGroup) {
+	s := &http.Server{Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Log.Info("serving http", "address", l.Addr().String())
+		err := s.Serve(l)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Log.Error("failed to serve http", "address", l.Addr().String(), "error", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err := s.Shutdown(shutdownCtx)
+		if err != nil {
+			log.Log.Error("failed to stop http server", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+	It("should serve until the context is done", func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		address := l.Addr().String()
+		Expect(l.Close()).To(Succeed())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var wg sync.WaitGroup
+		handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		})
+		Expect(Start(ctx, address, handler, &wg)).To(Succeed())
+
+		resp, err := http.Get("http://" + address)
+		Expect(err).NotTo(HaveOccurred())
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Body.Close()).To(Succeed())
+		Expect(string(body)).To(Equal("ok"))
+
+		cancel()
+		wg.Wait()
+		_, err = http.Get("http://" + address)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should serve on a unix socket until the context is done", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "status.sock")
+		// A socket left by a previous instance is replaced.
+		Expect(os.WriteFile(path, nil, 0o644)).To(Succeed())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var wg sync.WaitGroup
+		handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		})
+		Expect(StartUnix(ctx, path, handler, &wg)).To(Succeed())
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		}}
+		resp, err := client.Get("http://unix/status")
+		Expect(err).NotTo(HaveOccurred())
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Body.Close()).To(Succeed())
+		Expect(string(body)).To(Equal("ok"))
+
+		cancel()
+		wg.Wait()
+		_, err = os.Stat(path)
+		Expect(err).To(MatchError(os.ErrNotExist))
+	})
+
+	It("should return an error when the address cannot be listened on", func() {
+		var wg sync.WaitGroup
+		Expect(Start(context.Background(), "256.0.0.1:0", http.NotFoundHandler(), &wg)).NotTo(Succeed())
+	})
+})
@@ -3,15 +3,22 @@ package subscribe
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/vishvananda/netlink"
 
+	"github.com/openshift/pf-status-relay/pkg/health"
 	"github.com/openshift/pf-status-relay/pkg/log"
+	"github.com/openshift/pf-status-relay/pkg/metrics"
 )
 
-// Start starts subscription to link changes.
-func Start(ctx context.Context, indexes []int, queue chan<- int, wg *sync.WaitGroup) error {
-	log.Log.Debug("subscribing to link changes")
+// reconnectInterval is the time between attempts to subscribe again after the subscription failed.
+const reconnectInterval = time.Second
+
+// Start starts subscription to link changes. The routine beats hb while it waits for link changes, so that it is
+// reported as stalled when it is blocked.
+func Start(ctx context.Context, indexes []int, queue chan<- int, wg *sync.WaitGroup, hb *health.Heartbeat) error {
+	log.Subscribe.Debug("subscribing to link changes")
 	update := make(chan netlink.LinkUpdate)
 
 	// There is another function that allows to register an error handler which might be useful to retry subscription in case of errors.
@@ -23,19 +30,36 @@ func Start(ctx context.Context, indexes []int, queue chan<- int, wg *sync.WaitGr
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		ticker := time.NewTicker(hb.Interval())
+		defer ticker.Stop()
+
 		for {
+			hb.Beat()
 			select {
-			case u := <-update:
-				log.Log.Debug("event received", "index", u.Index)
+			case <-ticker.C:
+			case u, ok := <-update:
+				if !ok {
+					update = resubscribe(ctx, hb)
+					if update == nil {
+						return
+					}
+					// Links may have changed while the subscription was down.
+					for _, index := range indexes {
+						queue <- index
+					}
+					break
+				}
+
+				log.Subscribe.Debug("event received", "index", u.Index)
 				// Add index to the queue if there is a match.
 				for _, index := range indexes {
 					if int(u.Index) == index {
-						log.Log.Debug("adding index to queue", "index", index)
+						log.Subscribe.Debug("adding index to queue", "index", index)
 						queue <- index
 					}
 				}
 			case <-ctx.Done():
-				log.Log.Debug("ctx cancelled", "routine", "subscribe")
+				log.Subscribe.Debug("ctx cancelled", "routine", "subscribe")
 				return
 			}
 		}
@@ -43,3 +67,36 @@ func Start(ctx context.Context, indexes []int, queue chan<- int, wg *sync.WaitGr
 
 	return nil
 }
+
+// resubscribe subscribes to link changes again after the subscription failed, until ctx is done. It returns nil
+// when ctx is done.
+func resubscribe(ctx context.Context, hb *health.Heartbeat) chan netlink.LinkUpdate {
+	// The subscription is closed when ctx is done.
+	if ctx.Err() != nil {
+		log.Subscribe.Debug("ctx cancelled", "routine", "subscribe")
+		return nil
+	}
+	log.Subscribe.Warn("subscription to link changes failed")
+
+	for {
+		hb.Beat()
+		select {
+		case <-time.After(reconnectInterval):
+		case <-ctx.Done():
+			log.Subscribe.Debug("ctx cancelled", "routine", "subscribe")
+			return nil
+		}
+
+		update := make(chan netlink.LinkUpdate)
+		err := netlink.LinkSubscribe(update, ctx.Done())
+		if err != nil {
+			log.Subscribe.Warn("failed to subscribe to link changes", "error", err)
+			continue
+		}
+
+		log.Subscribe.Info("subscription to link changes was restored")
+		metrics.SubscriptionReconnects.Inc()
+
+		return update
+	}
+}
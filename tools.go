@@ -0,0 +1,8 @@
+//go:build tools
+
+// Package tools tracks the tools used to generate code, so that they are vendored along with the dependencies.
+package tools
+
+import (
+	_ "go.uber.org/mock/mockgen"
+)